@@ -31,6 +31,7 @@ import (
 	"github.com/conforma/knative-service/acceptance/knative"
 	"github.com/conforma/knative-service/acceptance/kubernetes"
 	"github.com/conforma/knative-service/acceptance/log"
+	"github.com/conforma/knative-service/acceptance/release"
 	"github.com/conforma/knative-service/acceptance/snapshot"
 	"github.com/conforma/knative-service/acceptance/tekton"
 	"github.com/conforma/knative-service/acceptance/testenv"
@@ -57,6 +58,7 @@ var seed = flag.Int64("seed", -1, "random seed to use for the tests")
 func initializeScenario(sc *godog.ScenarioContext) {
 	knative.AddStepsTo(sc)
 	kubernetes.AddStepsTo(sc)
+	release.AddStepsTo(sc)
 	snapshot.AddStepsTo(sc)
 	tekton.AddStepsTo(sc)
 	vsa.AddStepsTo(sc)
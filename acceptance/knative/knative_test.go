@@ -0,0 +1,68 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package knative
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForHealthy(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := waitForHealthy(context.Background(), server.URL, 10*time.Millisecond, 20*time.Millisecond, time.Second)
+
+	if err != nil {
+		t.Fatalf("expected the service to become healthy, got: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 health probes, got %d", calls.Load())
+	}
+}
+
+func TestWaitForHealthy_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("not ready"))
+	}))
+	defer server.Close()
+
+	err := waitForHealthy(context.Background(), server.URL, 10*time.Millisecond, 20*time.Millisecond, 50*time.Millisecond)
+
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := err.Error(); got != "service at "+server.URL+"/health not healthy, last status 503: not ready" {
+		t.Errorf("unexpected error: %s", got)
+	}
+}
@@ -376,6 +376,14 @@ func (k *kindCluster) KubeConfig(ctx context.Context) (string, error) {
 	}
 }
 
+func (k *kindCluster) Dynamic(_ context.Context) (dynamic.Interface, error) {
+	if k.dynamic == nil {
+		return nil, errors.New("dynamic client not initialized")
+	}
+
+	return k.dynamic, nil
+}
+
 func (k *kindCluster) Stop(ctx context.Context) (context.Context, error) {
 	logger, ctx := log.LoggerFor(ctx)
 
@@ -446,6 +454,17 @@ func (k *kindCluster) CreateNamespace(ctx context.Context) (context.Context, err
 	return ctx, nil
 }
 
+// WorkingNamespace returns the namespace created by CreateNamespace, or an
+// empty string if none was created
+func (k *kindCluster) WorkingNamespace(ctx context.Context) string {
+	t := testenv.FetchState[testState](ctx)
+	if t == nil {
+		return ""
+	}
+
+	return t.namespace
+}
+
 func (k *kindCluster) Registry(ctx context.Context) (string, error) {
 	return fmt.Sprintf("registry.image-registry.svc.cluster.local:%d", k.registryPort), nil
 }
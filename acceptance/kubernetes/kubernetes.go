@@ -21,6 +21,7 @@ import (
 	"errors"
 
 	"github.com/cucumber/godog"
+	"k8s.io/client-go/dynamic"
 
 	"github.com/conforma/knative-service/acceptance/kubernetes/kind"
 	"github.com/conforma/knative-service/acceptance/kubernetes/types"
@@ -61,6 +62,39 @@ func (c ClusterState) KubeConfig(ctx context.Context) (string, error) {
 	return c.cluster.KubeConfig(ctx)
 }
 
+// Dynamic returns a dynamic client for the cluster
+func (c ClusterState) Dynamic(ctx context.Context) (dynamic.Interface, error) {
+	if err := mustBeUp(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c.cluster.Dynamic(ctx)
+}
+
+// WorkingNamespace returns the namespace created by the `Given a working
+// namespace` step, or an empty string if none was created
+func (c ClusterState) WorkingNamespace(ctx context.Context) string {
+	if c.cluster == nil {
+		return ""
+	}
+
+	return c.cluster.WorkingNamespace(ctx)
+}
+
+// WithCluster sets up the ClusterState in the Context using the provided
+// cluster, used to run steps against a stub cluster
+func WithCluster(ctx context.Context, cluster types.Cluster) (context.Context, error) {
+	c := &ClusterState{}
+	ctx, err := testenv.SetupState(ctx, &c)
+	if err != nil {
+		return ctx, err
+	}
+
+	c.cluster = cluster
+
+	return ctx, nil
+}
+
 type startFunc func(context.Context) (context.Context, types.Cluster, error)
 
 // startAndSetupState starts the cluster via the provided startFunc. The
@@ -18,6 +18,8 @@ package types
 
 import (
 	"context"
+
+	"k8s.io/client-go/dynamic"
 )
 
 // Cluster represents a Kubernetes cluster interface for testing
@@ -26,5 +28,7 @@ type Cluster interface {
 	Stop(context.Context) (context.Context, error)
 	KubeConfig(context.Context) (string, error)
 	CreateNamespace(context.Context) (context.Context, error)
+	WorkingNamespace(context.Context) string
 	Registry(context.Context) (string, error)
+	Dynamic(context.Context) (dynamic.Interface, error)
 }
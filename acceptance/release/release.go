@@ -0,0 +1,164 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cucumber/godog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/conforma/knative-service/acceptance/kubernetes"
+	"github.com/conforma/knative-service/acceptance/testenv"
+)
+
+type key int
+
+const releaseStateKey = key(0)
+
+// rpaLabel is the label on a ReleasePlan naming its ReleasePlanAdmission
+const rpaLabel = "release.appstudio.openshift.io/releasePlanAdmission"
+
+var (
+	releasePlanGVR          = schema.GroupVersionResource{Group: "appstudio.redhat.com", Version: "v1alpha1", Resource: "releaseplans"}
+	releasePlanAdmissionGVR = schema.GroupVersionResource{Group: "appstudio.redhat.com", Version: "v1alpha1", Resource: "releaseplanadmissions"}
+	namespaceGVR            = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+// ReleaseState holds the ReleasePlan setup for the scenario
+type ReleaseState struct {
+	Application     string
+	TargetNamespace string
+	Policy          string
+}
+
+// Key implements the testenv.State interface
+func (r ReleaseState) Key() any {
+	return releaseStateKey
+}
+
+// ExpectedPolicy returns the POLICY_CONFIGURATION the service should resolve
+// from the ReleasePlanAdmission
+func (r ReleaseState) ExpectedPolicy() string {
+	return fmt.Sprintf("%s/%s", r.TargetNamespace, r.Policy)
+}
+
+// createReleasePlan creates a ReleasePlan for the application in the snapshot
+// namespace and the ReleasePlanAdmission it points to in the target namespace
+func createReleasePlan(ctx context.Context, application, target, policy string) (context.Context, error) {
+	r := &ReleaseState{}
+	ctx, err := testenv.SetupState(ctx, &r)
+	if err != nil {
+		return ctx, err
+	}
+
+	r.Application = application
+	r.TargetNamespace = target
+	r.Policy = policy
+
+	cluster := testenv.FetchState[kubernetes.ClusterState](ctx)
+	if cluster == nil || !cluster.Up(ctx) {
+		// For stub testing, only record the expected policy
+		// TODO: Remove when real implementation is added
+		return ctx, nil
+	}
+
+	dyn, err := cluster.Dynamic(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	if err := ensureNamespace(ctx, dyn, target); err != nil {
+		return ctx, fmt.Errorf("failed to create namespace %s: %w", target, err)
+	}
+
+	// The ReleasePlan goes where snapshots are created, the working namespace
+	// if there is one
+	namespace := cluster.WorkingNamespace(ctx)
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	rpaName := fmt.Sprintf("%s-rpa", application)
+
+	rpa := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "appstudio.redhat.com/v1alpha1",
+		"kind":       "ReleasePlanAdmission",
+		"metadata": map[string]any{
+			"name":      rpaName,
+			"namespace": target,
+		},
+		"spec": map[string]any{
+			"applications": []any{application},
+			// The RPA CRD requires the namespace of the ReleasePlans it admits
+			"origin": namespace,
+			"policy": policy,
+		},
+	}}
+	if _, err := dyn.Resource(releasePlanAdmissionGVR).Namespace(target).Create(ctx, rpa, metav1.CreateOptions{}); err != nil {
+		return ctx, fmt.Errorf("failed to create ReleasePlanAdmission: %w", err)
+	}
+
+	rp := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "appstudio.redhat.com/v1alpha1",
+		"kind":       "ReleasePlan",
+		"metadata": map[string]any{
+			"name":      fmt.Sprintf("%s-rp", application),
+			"namespace": namespace,
+			"labels": map[string]any{
+				rpaLabel: rpaName,
+			},
+		},
+		"spec": map[string]any{
+			"application": application,
+			"target":      target,
+		},
+	}}
+	if _, err := dyn.Resource(releasePlanGVR).Namespace(namespace).Create(ctx, rp, metav1.CreateOptions{}); err != nil {
+		return ctx, fmt.Errorf("failed to create ReleasePlan: %w", err)
+	}
+
+	return ctx, nil
+}
+
+// ensureNamespace creates the namespace unless it already exists
+func ensureNamespace(ctx context.Context, dyn dynamic.Interface, name string) error {
+	ns := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]any{
+			"name": name,
+		},
+	}}
+
+	_, err := dyn.Resource(namespaceGVR).Create(ctx, ns, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	return err
+}
+
+// AddStepsTo adds release-related steps to the scenario context
+func AddStepsTo(sc *godog.ScenarioContext) {
+	sc.Step(`^a ReleasePlan for application "([^"]*)" targeting namespace "([^"]*)" with policy "([^"]*)"$`, createReleasePlan)
+}
@@ -34,6 +34,10 @@ type key int
 
 const snapshotStateKey = key(0)
 
+// defaultNamespace is where snapshots are created when the scenario has no
+// working namespace
+const defaultNamespace = "default"
+
 // SnapshotState holds the state of snapshot resources
 type SnapshotState struct {
 	Snapshots     map[string]*unstructured.Unstructured
@@ -71,6 +75,21 @@ type Component struct {
 	ContainerImage string `json:"containerImage"`
 }
 
+// snapshotNamespace returns the cluster's working namespace, falling back to
+// the default namespace if no working namespace was created
+func snapshotNamespace(ctx context.Context) string {
+	cluster := testenv.FetchState[kubernetes.ClusterState](ctx)
+	if cluster == nil {
+		return defaultNamespace
+	}
+
+	if namespace := cluster.WorkingNamespace(ctx); namespace != "" {
+		return namespace
+	}
+
+	return defaultNamespace
+}
+
 // createValidSnapshot creates a valid snapshot from specification
 func createValidSnapshot(ctx context.Context, specification *godog.DocString) (context.Context, error) {
 	s := &SnapshotState{}
@@ -84,9 +103,7 @@ func createValidSnapshot(ctx context.Context, specification *godog.DocString) (c
 		s.Snapshots = make(map[string]*unstructured.Unstructured)
 	}
 
-	// Use default namespace for now
-	// In a real implementation, this would come from the cluster's working namespace
-	s.Namespace = "default"
+	s.Namespace = snapshotNamespace(ctx)
 
 	// Parse the specification
 	var spec SnapshotSpec
@@ -130,9 +147,7 @@ func createInvalidSnapshot(ctx context.Context, specification *godog.DocString)
 		s.Snapshots = make(map[string]*unstructured.Unstructured)
 	}
 
-	// Use default namespace for now
-	// In a real implementation, this would come from the cluster's working namespace
-	s.Namespace = "default"
+	s.Namespace = snapshotNamespace(ctx)
 
 	// Parse the specification (which should be invalid)
 	var spec SnapshotSpec
@@ -209,9 +224,7 @@ func createMultipleSnapshots(ctx context.Context, count int) (context.Context, e
 		s.Snapshots = make(map[string]*unstructured.Unstructured)
 	}
 
-	// Use default namespace for now
-	// In a real implementation, this would come from the cluster's working namespace
-	s.Namespace = "default"
+	s.Namespace = snapshotNamespace(ctx)
 
 	// Create multiple snapshots
 	for i := 0; i < count; i++ {
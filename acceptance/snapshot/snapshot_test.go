@@ -0,0 +1,93 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snapshot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cucumber/godog"
+
+	"github.com/conforma/knative-service/acceptance/kubernetes"
+	"github.com/conforma/knative-service/acceptance/kubernetes/types"
+	"github.com/conforma/knative-service/acceptance/testenv"
+)
+
+// stubCluster is a cluster with a fixed working namespace, the other methods
+// aren't used by snapshot creation
+type stubCluster struct {
+	types.Cluster
+	namespace string
+}
+
+func (s stubCluster) WorkingNamespace(context.Context) string {
+	return s.namespace
+}
+
+func TestCreateValidSnapshot_Namespace(t *testing.T) {
+	spec := &godog.DocString{Content: `{"application":"test-app","components":[{"name":"component","containerImage":"image"}]}`}
+
+	tests := []struct {
+		name     string
+		setup    func(context.Context) (context.Context, error)
+		expected string
+	}{
+		{
+			name:     "no cluster",
+			setup:    func(ctx context.Context) (context.Context, error) { return ctx, nil },
+			expected: "default",
+		},
+		{
+			name: "no working namespace",
+			setup: func(ctx context.Context) (context.Context, error) {
+				return kubernetes.WithCluster(ctx, stubCluster{})
+			},
+			expected: "default",
+		},
+		{
+			name: "working namespace",
+			setup: func(ctx context.Context) (context.Context, error) {
+				return kubernetes.WithCluster(ctx, stubCluster{namespace: "knative-test-abc12"})
+			},
+			expected: "knative-test-abc12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, err := tt.setup(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, err = createValidSnapshot(ctx, spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := testenv.FetchState[SnapshotState](ctx)
+			if s.Namespace != tt.expected {
+				t.Errorf("expected state namespace %q, got %q", tt.expected, s.Namespace)
+			}
+			for name, snapshot := range s.Snapshots {
+				if got := snapshot.GetNamespace(); got != tt.expected {
+					t.Errorf("%s: expected namespace %q, got %q", name, tt.expected, got)
+				}
+			}
+		})
+	}
+}
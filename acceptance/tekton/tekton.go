@@ -18,14 +18,19 @@ package tekton
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/cucumber/godog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/conforma/knative-service/acceptance/kubernetes"
+	"github.com/conforma/knative-service/acceptance/release"
 	"github.com/conforma/knative-service/acceptance/snapshot"
 	"github.com/conforma/knative-service/acceptance/testenv"
 )
@@ -34,6 +39,20 @@ type key int
 
 const tektonStateKey = key(0)
 
+var taskRunGVR = schema.GroupVersionResource{Group: "tekton.dev", Version: "v1", Resource: "taskruns"}
+
+// managedBySelector matches the TaskRuns created by the knative service
+const managedBySelector = "app.kubernetes.io/managed-by=conforma-knative-service"
+
+// Waits for TaskRuns poll with backoff between these intervals
+const (
+	pollInitialInterval = 2 * time.Second
+	pollMaxInterval     = 15 * time.Second
+)
+
+// requiredParams are the params the service sets on every TaskRun
+var requiredParams = []string{"IMAGES", "POLICY_CONFIGURATION", "PUBLIC_KEY", "VSA_UPLOAD_URL"}
+
 // TektonState holds the state of Tekton resources
 type TektonState struct {
 	taskRuns       map[string]*TaskRunInfo
@@ -55,6 +74,8 @@ type TaskRunInfo struct {
 	Results    map[string]string
 	Bundle     string
 	CreatedAt  time.Time
+	// Component is only set on simulated TaskRuns, which are per component
+	Component string
 }
 
 // verifyTaskRunCreated verifies that a TaskRun was created
@@ -81,7 +102,7 @@ func verifyTaskRunCreated(ctx context.Context) error {
 	}
 
 	// Wait for TaskRun to be created
-	err = wait.PollImmediate(5*time.Second, 2*time.Minute, func() (bool, error) {
+	err = testenv.PollWithBackoff(ctx, pollInitialInterval, pollMaxInterval, 2*time.Minute, func(ctx context.Context) (bool, error) {
 		taskRuns, err := findTaskRuns(ctx, cluster, "default")
 		if err != nil {
 			return false, err
@@ -136,7 +157,6 @@ func verifyTaskRunParameters(ctx context.Context) error {
 
 	for name, taskRun := range t.taskRuns {
 		// Verify required parameters are present
-		requiredParams := []string{"image", "policy", "public-key"}
 		for _, param := range requiredParams {
 			if _, exists := taskRun.Parameters[param]; !exists {
 				return fmt.Errorf("TaskRun %s missing required parameter: %s", name, param)
@@ -144,8 +164,61 @@ func verifyTaskRunParameters(ctx context.Context) error {
 		}
 
 		// Verify parameter values are reasonable
-		if taskRun.Parameters["image"] == "" {
-			return fmt.Errorf("TaskRun %s has empty image parameter", name)
+		if taskRun.Parameters["IMAGES"] == "" {
+			return fmt.Errorf("TaskRun %s has empty IMAGES parameter", name)
+		}
+	}
+
+	// When the scenario set up a ReleasePlan the policy must match its RPA
+	if r := testenv.FetchState[release.ReleaseState](ctx); r != nil {
+		return checkPolicy(r, t.taskRuns)
+	}
+
+	return nil
+}
+
+// verifyTaskRunPolicy verifies that POLICY_CONFIGURATION matches the policy
+// in the ReleasePlanAdmission set up for the scenario
+func verifyTaskRunPolicy(ctx context.Context) error {
+	r := testenv.FetchState[release.ReleaseState](ctx)
+	if r == nil {
+		return fmt.Errorf("no ReleasePlan configured")
+	}
+
+	t := &TektonState{}
+	ctx, err := testenv.SetupState(ctx, &t)
+	if err != nil {
+		return err
+	}
+
+	// If no TaskRuns exist yet, fetch them from cluster
+	if len(t.taskRuns) == 0 {
+		cluster := testenv.FetchState[kubernetes.ClusterState](ctx)
+		if cluster == nil {
+			return fmt.Errorf("cluster not initialized")
+		}
+
+		taskRuns, err := findTaskRuns(ctx, cluster, "default")
+		if err != nil {
+			return err
+		}
+		t.taskRuns = taskRuns
+	}
+
+	if len(t.taskRuns) == 0 {
+		return fmt.Errorf("no TaskRuns found")
+	}
+
+	return checkPolicy(r, t.taskRuns)
+}
+
+// checkPolicy compares each TaskRun's POLICY_CONFIGURATION with the policy
+// the service should have resolved
+func checkPolicy(r *release.ReleaseState, taskRuns map[string]*TaskRunInfo) error {
+	expected := r.ExpectedPolicy()
+	for name, taskRun := range taskRuns {
+		if actual := taskRun.Parameters["POLICY_CONFIGURATION"]; actual != expected {
+			return fmt.Errorf("TaskRun %s has POLICY_CONFIGURATION %q, expected %q", name, actual, expected)
 		}
 	}
 
@@ -232,7 +305,7 @@ func verifyTaskRunSuccess(ctx context.Context) error {
 	}
 
 	// Wait for TaskRuns to complete
-	return wait.PollImmediate(10*time.Second, 10*time.Minute, func() (bool, error) {
+	return testenv.PollWithBackoff(ctx, pollInitialInterval, pollMaxInterval, 10*time.Minute, func(ctx context.Context) (bool, error) {
 		// Update TaskRun status
 		updatedTaskRuns, err := findTaskRuns(ctx, cluster, "default")
 		if err != nil {
@@ -284,7 +357,7 @@ func verifyMultipleTaskRuns(ctx context.Context) error {
 
 	// Wait for TaskRuns to be created
 	expectedCount := 2 // Based on the multi-component scenario
-	err = wait.PollImmediate(5*time.Second, 2*time.Minute, func() (bool, error) {
+	err = testenv.PollWithBackoff(ctx, pollInitialInterval, pollMaxInterval, 2*time.Minute, func(ctx context.Context) (bool, error) {
 		taskRuns, err := findTaskRuns(ctx, cluster, "default")
 		if err != nil {
 			return false, err
@@ -375,7 +448,7 @@ func verifyTaskRunsCompleteWithinTime(ctx context.Context, timeoutSeconds int) e
 	startTime := time.Now()
 	timeout := time.Duration(timeoutSeconds) * time.Second
 
-	return wait.PollImmediate(5*time.Second, timeout, func() (bool, error) {
+	return testenv.PollWithBackoff(ctx, pollInitialInterval, pollMaxInterval, timeout, func(ctx context.Context) (bool, error) {
 		if time.Since(startTime) > timeout {
 			return false, fmt.Errorf("TaskRuns did not complete within %d seconds", timeoutSeconds)
 		}
@@ -402,31 +475,136 @@ func verifyTaskRunsCompleteWithinTime(ctx context.Context, timeoutSeconds int) e
 	})
 }
 
-// findTaskRuns finds TaskRuns in the specified namespace
+// findTaskRuns finds the TaskRuns created by the service in the specified
+// namespace
 func findTaskRuns(ctx context.Context, cluster *kubernetes.ClusterState, namespace string) (map[string]*TaskRunInfo, error) {
-	// Implementation would use Tekton client to list TaskRuns
-	// This is a placeholder for the actual Kubernetes API call
+	if cluster == nil || !cluster.Up(ctx) {
+		// For stub testing, simulate the TaskRuns the service would create
+		// TODO: Remove when real implementation is added
+		return simulateTaskRuns(ctx, namespace), nil
+	}
+
+	dyn, err := cluster.Dynamic(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := dyn.Resource(taskRunGVR).Namespace(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: managedBySelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TaskRuns in %s: %w", namespace, err)
+	}
+
+	taskRuns := make(map[string]*TaskRunInfo, len(list.Items))
+	for i := range list.Items {
+		info := toTaskRunInfo(&list.Items[i])
+		taskRuns[info.Name] = info
+	}
+
+	return taskRuns, nil
+}
+
+// toTaskRunInfo extracts the fields the steps check from a TaskRun
+func toTaskRunInfo(obj *unstructured.Unstructured) *TaskRunInfo {
+	info := &TaskRunInfo{
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Status:     taskRunStatus(obj),
+		Parameters: map[string]string{},
+		Results:    map[string]string{},
+		CreatedAt:  obj.GetCreationTimestamp().Time,
+	}
+
+	params, _, _ := unstructured.NestedSlice(obj.Object, "spec", "params")
+	for _, p := range params {
+		param, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(param, "name")
+		// Array and object params aren't used by the service
+		if value, ok := param["value"].(string); ok {
+			info.Parameters[name] = value
+		}
+	}
+
+	resolverParams, _, _ := unstructured.NestedSlice(obj.Object, "spec", "taskRef", "params")
+	for _, p := range resolverParams {
+		param, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(param, "name"); name == "bundle" {
+			info.Bundle, _, _ = unstructured.NestedString(param, "value")
+		}
+	}
+
+	results, _, _ := unstructured.NestedSlice(obj.Object, "status", "results")
+	for _, r := range results {
+		result, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(result, "name")
+		if value, ok := result["value"].(string); ok {
+			info.Results[name] = value
+		}
+	}
+
+	return info
+}
+
+// taskRunStatus maps the TaskRun's Succeeded condition to the status names
+// used by the steps
+func taskRunStatus(obj *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != "Succeeded" {
+			continue
+		}
+		switch condition["status"] {
+		case "True":
+			return "Succeeded"
+		case "False":
+			return "Failed"
+		default:
+			return "Running"
+		}
+	}
+
+	return "Pending"
+}
+
+// simulateTaskRuns returns the TaskRuns the service would create for the
+// snapshots in the scenario, for use when there's no cluster to query
+func simulateTaskRuns(ctx context.Context, namespace string) map[string]*TaskRunInfo {
 	taskRuns := make(map[string]*TaskRunInfo)
 
 	// Check if we have snapshot state - only return TaskRuns if snapshots exist
 	snapshotState := testenv.FetchState[snapshot.SnapshotState](ctx)
 	if snapshotState == nil {
 		// No snapshots, so no TaskRuns should exist
-		return taskRuns, nil
+		return taskRuns
 	}
 
 	// If an invalid snapshot exists, don't create TaskRuns
 	// This simulates the controller rejecting invalid snapshots
 	if snapshotState.InvalidExists {
-		return taskRuns, nil
+		return taskRuns
 	}
 
-	// Mock implementation - in real code this would query the cluster
-	// Only create mock TaskRuns if we have valid snapshots
-	// This simulates the controller creating TaskRuns in response to snapshots
-	// Create one TaskRun per component in all snapshots
+	policy := "enterprise-contract-service/registry-standard"
+	if r := testenv.FetchState[release.ReleaseState](ctx); r != nil {
+		policy = r.ExpectedPolicy()
+	}
+
+	// Create one TaskRun per component in all snapshots. Snapshots and
+	// components are visited in name order so the TaskRun names are stable.
 	taskRunIndex := 0
-	for _, snapshotObj := range snapshotState.Snapshots {
+	for _, snapshotName := range slices.Sorted(maps.Keys(snapshotState.Snapshots)) {
+		snapshotObj := snapshotState.Snapshots[snapshotName]
 		// Extract components from the snapshot
 		spec, found, err := unstructured.NestedMap(snapshotObj.Object, "spec")
 		if err != nil || !found {
@@ -438,16 +616,24 @@ func findTaskRuns(ctx context.Context, cluster *kubernetes.ClusterState, namespa
 			continue
 		}
 
-		// Create a TaskRun for each component
+		images, err := json.Marshal(spec)
+		if err != nil {
+			continue
+		}
+
+		componentNames := make([]string, 0, len(components))
 		for _, comp := range components {
-			componentMap, ok := comp.(map[string]interface{})
+			componentMap, ok := comp.(map[string]any)
 			if !ok {
 				continue
 			}
+			name, _, _ := unstructured.NestedString(componentMap, "name")
+			componentNames = append(componentNames, name)
+		}
+		slices.Sort(componentNames)
 
-			componentName, _, _ := unstructured.NestedString(componentMap, "name")
-			containerImage, _, _ := unstructured.NestedString(componentMap, "containerImage")
-
+		// Create a TaskRun for each component
+		for _, componentName := range componentNames {
 			taskRunIndex++
 			taskRunName := fmt.Sprintf("test-taskrun-%d", taskRunIndex)
 
@@ -456,24 +642,26 @@ func findTaskRuns(ctx context.Context, cluster *kubernetes.ClusterState, namespa
 				Namespace: namespace,
 				Status:    "Succeeded",
 				Parameters: map[string]string{
-					"image":      containerImage,
-					"policy":     "enterprise-contract-policy",
-					"public-key": "test-key",
-					"component":  componentName,
+					"IMAGES":               string(images),
+					"POLICY_CONFIGURATION": policy,
+					"PUBLIC_KEY":           "k8s://openshift-pipelines/public-key",
+					"VSA_UPLOAD_URL":       "rekor@https://rekor.sigstore.dev",
 				},
 				Bundle:    "quay.io/enterprise-contract/ec-task-bundle:latest",
 				CreatedAt: time.Now(),
+				Component: componentName,
 			}
 		}
 	}
 
-	return taskRuns, nil
+	return taskRuns
 }
 
 // AddStepsTo adds Tekton-related steps to the scenario context
 func AddStepsTo(sc *godog.ScenarioContext) {
 	sc.Step(`^a TaskRun should be created$`, verifyTaskRunCreated)
 	sc.Step(`^the TaskRun should have the correct parameters$`, verifyTaskRunParameters)
+	sc.Step(`^the TaskRun should use the policy from the ReleasePlanAdmission$`, verifyTaskRunPolicy)
 	sc.Step(`^the TaskRun should reference the enterprise contract bundle$`, verifyTaskRunBundle)
 	sc.Step(`^the TaskRun should succeed$`, verifyTaskRunSuccess)
 	sc.Step(`^a TaskRun should be created for each component$`, verifyMultipleTaskRuns)
@@ -0,0 +1,69 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tekton
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/conforma/knative-service/acceptance/snapshot"
+	"github.com/conforma/knative-service/acceptance/testenv"
+)
+
+func TestSimulateTaskRuns_ComponentOrder(t *testing.T) {
+	s := &snapshot.SnapshotState{}
+	ctx, err := testenv.SetupState(context.Background(), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Snapshots = map[string]*unstructured.Unstructured{
+		"snapshot-b": {Object: map[string]any{
+			"spec": map[string]any{
+				"components": []any{
+					map[string]any{"name": "zeta"},
+					map[string]any{"name": "eta"},
+				},
+			},
+		}},
+		"snapshot-a": {Object: map[string]any{
+			"spec": map[string]any{
+				"components": []any{
+					map[string]any{"name": "gamma"},
+					map[string]any{"name": "alpha"},
+					map[string]any{"name": "beta"},
+				},
+			},
+		}},
+	}
+
+	taskRuns := simulateTaskRuns(ctx, "default")
+
+	expected := []string{"alpha", "beta", "gamma", "eta", "zeta"}
+	if len(taskRuns) != len(expected) {
+		t.Fatalf("expected %d TaskRuns, got %d", len(expected), len(taskRuns))
+	}
+	for i, component := range expected {
+		name := fmt.Sprintf("test-taskrun-%d", i+1)
+		if got := taskRuns[name].Component; got != component {
+			t.Errorf("%s: expected component %q, got %q", name, component, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// pollJitter is the fraction of each delay that may be randomly taken off, so
+// waits don't line up with whatever they're polling
+const pollJitter = 0.2
+
+// backoff produces exponentially growing delays, doubling from the initial
+// delay up to max, each reduced by a random jitter
+type backoff struct {
+	next   time.Duration
+	max    time.Duration
+	jitter float64
+	random func() float64
+}
+
+func newBackoff(initial, max time.Duration) *backoff {
+	return &backoff{next: initial, max: max, jitter: pollJitter, random: rand.Float64}
+}
+
+// delay returns the time to wait before the next attempt
+func (b *backoff) delay() time.Duration {
+	d := min(b.next, b.max)
+	b.next = min(b.next*2, b.max)
+	return d - time.Duration(b.jitter*b.random()*float64(d))
+}
+
+// PollWithBackoff calls fn until it returns true or an error, waiting between
+// attempts with exponential backoff starting at initial and capped at max. The
+// first attempt is made straight away. It gives up once timeout has passed or
+// ctx is done.
+func PollWithBackoff(ctx context.Context, initial, max, timeout time.Duration, fn func(context.Context) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	b := newBackoff(initial, max)
+	for {
+		done, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		timer := time.NewTimer(b.delay())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
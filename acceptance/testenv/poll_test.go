@@ -0,0 +1,85 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testenv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Progression(t *testing.T) {
+	b := newBackoff(time.Second, 10*time.Second)
+	b.random = func() float64 { return 0 }
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := b.delay(); got != want {
+			t.Errorf("delay %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	b := newBackoff(time.Second, 10*time.Second)
+	b.random = func() float64 { return 1 }
+
+	// The full jitter takes 20% off
+	if got := b.delay(); got != 800*time.Millisecond {
+		t.Errorf("expected 800ms, got %s", got)
+	}
+	if got := b.delay(); got != 1600*time.Millisecond {
+		t.Errorf("expected 1.6s, got %s", got)
+	}
+}
+
+func TestPollWithBackoff(t *testing.T) {
+	calls := 0
+	err := PollWithBackoff(context.Background(), time.Millisecond, 5*time.Millisecond, time.Second, func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestPollWithBackoff_Error(t *testing.T) {
+	boom := errors.New("boom")
+	err := PollWithBackoff(context.Background(), time.Millisecond, time.Millisecond, time.Second, func(context.Context) (bool, error) {
+		return false, boom
+	})
+
+	if !errors.Is(err, boom) {
+		t.Errorf("expected boom, got %v", err)
+	}
+}
+
+func TestPollWithBackoff_Timeout(t *testing.T) {
+	err := PollWithBackoff(context.Background(), time.Millisecond, 5*time.Millisecond, 20*time.Millisecond, func(context.Context) (bool, error) {
+		return false, nil
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/k8s"
+	gozap "go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// defaultAuditConfigMapName is used when AUDIT_CONFIGMAP_NAME isn't set
+	defaultAuditConfigMapName = "conforma-audit"
+	// defaultAuditMaxRecords bounds the audit ConfigMap when
+	// AUDIT_MAX_RECORDS isn't set
+	defaultAuditMaxRecords = 500
+	// auditMaxBytes keeps the audit ConfigMap well under the 1MiB limit on
+	// Kubernetes objects however large the records are
+	auditMaxBytes = 512 * 1024
+	// auditRecordsKey is the ConfigMap key holding the records, one JSON
+	// object per line, oldest first
+	auditRecordsKey = "records"
+	// auditWriteTimeout bounds writing a batch of records, which happens in
+	// the background and so isn't covered by PROCESS_TIMEOUT_SECONDS
+	auditWriteTimeout = 10 * time.Second
+	// auditQueueSize is how many records may wait to be written before new
+	// ones are dropped
+	auditQueueSize = 1000
+)
+
+// auditRecord is the audit trail entry for one processed snapshot
+type auditRecord struct {
+	Time              time.Time `json:"time"`
+	Snapshot          string    `json:"snapshot"`
+	SnapshotNamespace string    `json:"snapshotNamespace"`
+	Application       string    `json:"application,omitempty"`
+	Policy            string    `json:"policy,omitempty"`
+	TaskRun           string    `json:"taskRun,omitempty"`
+	Outcome           string    `json:"outcome"`
+	SkipReason        string    `json:"skipReason,omitempty"`
+}
+
+// auditConfigMaps is the part of the ConfigMap client the audit log uses
+type auditConfigMaps interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error)
+	Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error)
+	Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error)
+}
+
+// auditLog is an optional durable record of every verification decision,
+// enabled with AUDIT_ENABLED. Records are appended to a ConfigMap. Once it
+// holds maxRecords records, or is close to the size limit for Kubernetes
+// objects, its records move to the "<name>-previous" ConfigMap, replacing
+// what was there, and it starts over. At most twice maxRecords records are
+// kept, so the records should be collected before they're rotated out.
+//
+// Records are queued and written in batches by a background writer, so
+// processing snapshots doesn't wait on the API server.
+type auditLog struct {
+	queue      chan auditRecord
+	configMaps auditConfigMaps
+	namespace  string
+	name       string
+	maxRecords int
+	now        func() time.Time
+}
+
+func newAuditLog(configMaps auditConfigMaps, namespace, name string, maxRecords int) *auditLog {
+	if name == "" {
+		name = defaultAuditConfigMapName
+	}
+	if maxRecords <= 0 {
+		maxRecords = defaultAuditMaxRecords
+	}
+	return &auditLog{
+		queue:      make(chan auditRecord, auditQueueSize),
+		configMaps: configMaps,
+		namespace:  namespace,
+		name:       name,
+		maxRecords: maxRecords,
+		now:        time.Now,
+	}
+}
+
+// newAuditConfigMaps returns a client for the ConfigMaps in the audit namespace
+func newAuditConfigMaps(namespace string) (auditConfigMaps, error) {
+	k8sConfig, err := k8s.NewK8sConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	return client.CoreV1().ConfigMaps(namespace), nil
+}
+
+// rotatedName is the ConfigMap holding the records rotated out
+func (a *auditLog) rotatedName() string {
+	return a.name + "-previous"
+}
+
+// append adds records to the audit ConfigMap, rotating it when it's full.
+// It's only called by the background writer, so calls don't overlap.
+func (a *auditLog) append(ctx context.Context, records ...auditRecord) error {
+	lines := make([]string, 0, len(records))
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit record: %w", err)
+		}
+		lines = append(lines, string(line)+"\n")
+	}
+
+	// Other replicas write to the same ConfigMap, so conflicting updates are
+	// retried with a fresh copy
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := a.configMaps.Get(ctx, a.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			current, err = nil, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var records string
+		if current != nil {
+			records = current.Data[auditRecordsKey]
+		}
+		for _, line := range lines {
+			if strings.Count(records, "\n") >= a.maxRecords || len(records)+len(line) > auditMaxBytes {
+				if err := a.rotate(ctx, records); err != nil {
+					return err
+				}
+				records = ""
+			}
+			records += line
+		}
+
+		if current == nil {
+			_, err = a.configMaps.Create(ctx, a.newConfigMap(a.name, records), metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Another replica created it first
+				return apierrors.NewConflict(corev1.Resource("configmaps"), a.name, err)
+			}
+			return err
+		}
+		if current.Data == nil {
+			current.Data = map[string]string{}
+		}
+		current.Data[auditRecordsKey] = records
+		_, err = a.configMaps.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// rotate replaces the rotated ConfigMap's records with the given ones
+func (a *auditLog) rotate(ctx context.Context, records string) error {
+	rotated, err := a.configMaps.Get(ctx, a.rotatedName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = a.configMaps.Create(ctx, a.newConfigMap(a.rotatedName(), records), metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to rotate audit records: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rotate audit records: %w", err)
+	}
+	rotated.Data = map[string]string{auditRecordsKey: records}
+	if _, err := a.configMaps.Update(ctx, rotated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to rotate audit records: %w", err)
+	}
+	return nil
+}
+
+func (a *auditLog) newConfigMap(name, records string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: a.namespace,
+			Labels:    map[string]string{managedByLabel: managedByValue},
+		},
+		Data: map[string]string{auditRecordsKey: records},
+	}
+}
+
+// auditPermissions lists what the audit log does with the Kubernetes API, in
+// addition to requiredPermissions
+func auditPermissions(namespace string) []rbacPermission {
+	return []rbacPermission{
+		{verb: "get", resource: "configmaps", namespace: namespace},
+		{verb: "create", resource: "configmaps", namespace: namespace},
+		{verb: "update", resource: "configmaps", namespace: namespace},
+	}
+}
+
+// useAuditLog makes the service record every processed snapshot in the
+// audit log, starting the background writer
+func (s *Service) useAuditLog(log *auditLog) {
+	s.auditLog = log
+	s.runInBackground(s.writeAuditRecords)
+}
+
+// writeAuditRecords writes the queued audit records, batching the ones queued
+// while a write was in progress. Once the service is closed, the records
+// still queued are written before it returns.
+func (s *Service) writeAuditRecords(ctx context.Context) {
+	for {
+		select {
+		case record := <-s.auditLog.queue:
+			s.writeAuditBatch(s.auditLog.drain(record))
+		case <-ctx.Done():
+			if records := s.auditLog.drain(); len(records) > 0 {
+				s.writeAuditBatch(records)
+			}
+			return
+		}
+	}
+}
+
+// drain returns the given records followed by the ones queued
+func (a *auditLog) drain(records ...auditRecord) []auditRecord {
+	for {
+		select {
+		case record := <-a.queue:
+			records = append(records, record)
+		default:
+			return records
+		}
+	}
+}
+
+// writeAuditBatch appends records to the audit log. The snapshots have
+// already been handled, so failures are only logged.
+func (s *Service) writeAuditBatch(records []auditRecord) {
+	// Not tied to the service context, so records are still written while
+	// it's closing
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+
+	if err := s.auditLog.append(ctx, records...); err != nil {
+		snapshots := make([]string, 0, len(records))
+		for _, record := range records {
+			snapshots = append(snapshots, record.SnapshotNamespace+"/"+record.Snapshot)
+		}
+		s.logger.Error(err, "Failed to write audit records", gozap.Strings("snapshots", snapshots))
+	}
+}
+
+// recordAudit queues the outcome of processing a snapshot for the audit log,
+// if enabled. If the writer has fallen too far behind the record is dropped
+// rather than holding up processing.
+func (s *Service) recordAudit(summary *processSummary) {
+	if s.auditLog == nil {
+		return
+	}
+
+	record := auditRecord{
+		Time:              s.auditLog.now().UTC(),
+		Snapshot:          summary.snapshot.Name,
+		SnapshotNamespace: summary.snapshot.Namespace,
+		Application:       summary.application,
+		Policy:            summary.policy,
+		TaskRun:           summary.taskRunName,
+		Outcome:           summary.outcome,
+		SkipReason:        summary.skipReason,
+	}
+	select {
+	case s.auditLog.queue <- record:
+	default:
+		s.logger.Error(fmt.Errorf("audit queue is full"), "Dropped audit record",
+			gozap.String("snapshot", summary.snapshot.Name),
+			gozap.String("outcome", summary.outcome))
+	}
+}
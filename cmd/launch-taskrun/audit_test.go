@@ -0,0 +1,204 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/konflux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// readAuditRecords returns the snapshot names recorded in an audit ConfigMap
+func readAuditRecords(t *testing.T, configMaps auditConfigMaps, name string) []string {
+	t.Helper()
+	configMap, err := configMaps.Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	var snapshots []string
+	for _, line := range strings.Split(strings.TrimSuffix(configMap.Data[auditRecordsKey], "\n"), "\n") {
+		var record auditRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		snapshots = append(snapshots, record.Snapshot)
+	}
+	return snapshots
+}
+
+func TestAuditLog_Append(t *testing.T) {
+	configMaps := k8sfake.NewClientset().CoreV1().ConfigMaps("audit-namespace")
+	audit := newAuditLog(configMaps, "audit-namespace", "", 10)
+
+	for _, snapshot := range []string{"snapshot-1", "snapshot-2", "snapshot-3"} {
+		require.NoError(t, audit.append(context.Background(), auditRecord{Snapshot: snapshot, Outcome: outcomeCreated}))
+	}
+
+	assert.Equal(t, []string{"snapshot-1", "snapshot-2", "snapshot-3"}, readAuditRecords(t, configMaps, defaultAuditConfigMapName))
+	_, err := configMaps.Get(context.Background(), defaultAuditConfigMapName+"-previous", metav1.GetOptions{})
+	assert.Error(t, err, "nothing should be rotated yet")
+}
+
+func TestAuditLog_Rotate(t *testing.T) {
+	configMaps := k8sfake.NewClientset().CoreV1().ConfigMaps("audit-namespace")
+	audit := newAuditLog(configMaps, "audit-namespace", "audit", 2)
+	appendRecords := func(snapshots ...string) {
+		for _, snapshot := range snapshots {
+			require.NoError(t, audit.append(context.Background(), auditRecord{Snapshot: snapshot, Outcome: outcomeCreated}))
+		}
+	}
+
+	appendRecords("snapshot-1", "snapshot-2", "snapshot-3")
+	assert.Equal(t, []string{"snapshot-3"}, readAuditRecords(t, configMaps, "audit"))
+	assert.Equal(t, []string{"snapshot-1", "snapshot-2"}, readAuditRecords(t, configMaps, "audit-previous"))
+
+	// The second rotation replaces the first one's records
+	appendRecords("snapshot-4", "snapshot-5")
+	assert.Equal(t, []string{"snapshot-5"}, readAuditRecords(t, configMaps, "audit"))
+	assert.Equal(t, []string{"snapshot-3", "snapshot-4"}, readAuditRecords(t, configMaps, "audit-previous"))
+}
+
+func TestAuditLog_AppendBatch(t *testing.T) {
+	configMaps := k8sfake.NewClientset().CoreV1().ConfigMaps("audit-namespace")
+	audit := newAuditLog(configMaps, "audit-namespace", "audit", 2)
+
+	var records []auditRecord
+	for _, snapshot := range []string{"snapshot-1", "snapshot-2", "snapshot-3"} {
+		records = append(records, auditRecord{Snapshot: snapshot, Outcome: outcomeCreated})
+	}
+	require.NoError(t, audit.append(context.Background(), records...))
+
+	// A batch is rotated as if its records were appended one at a time
+	assert.Equal(t, []string{"snapshot-3"}, readAuditRecords(t, configMaps, "audit"))
+	assert.Equal(t, []string{"snapshot-1", "snapshot-2"}, readAuditRecords(t, configMaps, "audit-previous"))
+}
+
+func TestProcessSnapshot_RecordsAudit(t *testing.T) {
+	configMaps := k8sfake.NewClientset().CoreV1().ConfigMaps("audit-namespace")
+	audit := newAuditLog(configMaps, "audit-namespace", "", 0)
+	audit.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	service.useAuditLog(audit)
+
+	snapshot := &konflux.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-snapshot",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{skipAnnotation: "true"},
+		},
+		Spec: json.RawMessage(`{"application":"test-application"}`),
+	}
+
+	result, err := service.processSnapshot(context.Background(), snapshot)
+	// Closing the service writes the queued records
+	service.Close()
+
+	require.NoError(t, err)
+	assert.Equal(t, resultIgnored, result)
+	configMap, err := configMaps.Get(context.Background(), defaultAuditConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"time": "2025-01-02T03:04:05Z",
+		"snapshot": "test-snapshot",
+		"snapshotNamespace": "test-namespace",
+		"application": "test-application",
+		"outcome": "skipped",
+		"skipReason": "skip_annotation"
+	}`, configMap.Data[auditRecordsKey])
+}
+
+// expiringConfigMaps fails calls made with a context that's done, as the API
+// client would
+type expiringConfigMaps struct {
+	auditConfigMaps
+}
+
+func (e expiringConfigMaps) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.auditConfigMaps.Get(ctx, name, opts)
+}
+
+func TestProcessSnapshot_RecordsAuditAfterTimeout(t *testing.T) {
+	configMaps := k8sfake.NewClientset().CoreV1().ConfigMaps("audit-namespace")
+	audit := newAuditLog(expiringConfigMaps{configMaps}, "audit-namespace", "", 0)
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{
+		ProcessTimeout: time.Nanosecond,
+	})
+	service.useAuditLog(audit)
+
+	snapshot := &konflux.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-snapshot",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{skipAnnotation: "true"},
+		},
+		Spec: json.RawMessage(`{"application":"test-application"}`),
+	}
+
+	_, err := service.processSnapshot(context.Background(), snapshot)
+	service.Close()
+
+	// The processing context has expired by the time the record is written
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-snapshot"}, readAuditRecords(t, configMaps, defaultAuditConfigMapName))
+}
+
+// blockingConfigMaps holds up reading the audit ConfigMap until released
+type blockingConfigMaps struct {
+	auditConfigMaps
+	release chan struct{}
+}
+
+func (b blockingConfigMaps) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error) {
+	<-b.release
+	return b.auditConfigMaps.Get(ctx, name, opts)
+}
+
+func TestProcessSnapshot_DoesNotWaitForAudit(t *testing.T) {
+	configMaps := k8sfake.NewClientset().CoreV1().ConfigMaps("audit-namespace")
+	release := make(chan struct{})
+	audit := newAuditLog(blockingConfigMaps{configMaps, release}, "audit-namespace", "", 0)
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	service.useAuditLog(audit)
+
+	for _, name := range []string{"snapshot-1", "snapshot-2", "snapshot-3"} {
+		snapshot := &konflux.Snapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "test-namespace",
+				Annotations: map[string]string{skipAnnotation: "true"},
+			},
+			Spec: json.RawMessage(`{"application":"test-application"}`),
+		}
+		// Returns although the audit ConfigMap can't be read yet
+		_, err := service.processSnapshot(context.Background(), snapshot)
+		require.NoError(t, err)
+	}
+
+	close(release)
+	service.Close()
+
+	assert.Equal(t, []string{"snapshot-1", "snapshot-2", "snapshot-3"}, readAuditRecords(t, configMaps, defaultAuditConfigMapName))
+}
@@ -0,0 +1,99 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// debugConfigResponse is the JSON body served by /debug/config
+type debugConfigResponse struct {
+	Namespace string `json:"namespace"`
+	// Cached is true when the config came from the config cache rather than
+	// the cluster
+	Cached bool           `json:"cached"`
+	Config *TaskRunConfig `json:"config"`
+	// Effective holds the settings resolved from the config, with defaults
+	// applied
+	Effective debugEffectiveConfig `json:"effective"`
+}
+
+type debugEffectiveConfig struct {
+	TaskKind                 string         `json:"taskKind,omitempty"`
+	PolicyResolutionOrder    []policySource `json:"policyResolutionOrder,omitempty"`
+	ReleasePlanAmbiguityMode string         `json:"releasePlanAmbiguityMode,omitempty"`
+	// Namespaces searched for a ReleasePlan after the snapshot's namespace
+	ReleasePlanSearchNamespaces []string `json:"releasePlanSearchNamespaces,omitempty"`
+	// Errors are the reasons createTaskRun would refuse the config
+	Errors []string `json:"errors,omitempty"`
+}
+
+// serveDebugConfig returns the config the service parsed for the namespace
+// given by the namespace query parameter. Only the config map is read, so
+// secrets it names are listed but their contents are never fetched. The cached
+// config is returned if there is one, otherwise the config map is read without
+// caching it, changing the cache TTL or marking the service ready, so looking
+// doesn't change what the service does.
+func (s *Service) serveDebugConfig(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		http.Error(w, "the namespace query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	config, cached := s.configCache.peek(namespace)
+	if !cached {
+		data, err := s.fetchConfigMapData(r.Context(), namespace)
+		if apierrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		config = s.parseTaskRunConfig(data)
+	}
+
+	resp := debugConfigResponse{Namespace: namespace, Cached: cached, Config: config}
+	if kind, err := taskKind(config); err == nil {
+		resp.Effective.TaskKind = kind
+	}
+	if order, err := parsePolicyResolutionOrder(config.PolicyResolutionOrder); err == nil {
+		resp.Effective.PolicyResolutionOrder = order
+	}
+	if opts, err := releasePlanLookupOptions(config); err == nil {
+		resp.Effective.ReleasePlanAmbiguityMode = string(opts.AmbiguityMode)
+		resp.Effective.ReleasePlanSearchNamespaces = opts.SearchNamespaces
+	}
+	for _, err := range splitJoinedErrors(validateTaskRunConfig(config)) {
+		resp.Effective.Errors = append(resp.Effective.Errors, err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(resp); encodeErr != nil {
+		log.Printf("Debug config response write failed: %v", encodeErr)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	gozap "go.uber.org/zap"
+)
+
+// defaultEventLogMaxEntries bounds the event log when EVENT_LOG_MAX_ENTRIES
+// isn't set
+const defaultEventLogMaxEntries = 1000
+
+// errEventLogFull is returned when appending to an event log that is at its
+// bound. The event is rejected so the sender redelivers it later.
+var errEventLogFull = errors.New("event log is full")
+
+// eventLog is an optional write-ahead log of events being processed, enabled
+// with EVENT_LOG_DIR. Events are written to disk before processing and removed
+// once processed, so events in flight when the pod dies can be replayed on
+// startup. Each event is a file named after its source and ID, so redelivery
+// of the same event overwrites rather than duplicates it. As copies of an
+// event may be processed at once, an entry is only removed once no delivery
+// holds it.
+type eventLog struct {
+	mu         sync.Mutex
+	dir        string
+	maxEntries int
+	keys       map[string]struct{}
+	// holders counts the deliveries of each event being processed
+	holders map[string]int
+	now     func() time.Time
+}
+
+type eventLogEntry struct {
+	Received time.Time         `json:"received"`
+	Event    cloudevents.Event `json:"event"`
+}
+
+const eventLogFileSuffix = ".json"
+
+func newEventLog(dir string, maxEntries int) (*eventLog, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create event log directory %s: %w", dir, err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event log directory %s: %w", dir, err)
+	}
+	keys := make(map[string]struct{})
+	for _, file := range files {
+		if key, ok := strings.CutSuffix(file.Name(), eventLogFileSuffix); ok && file.Type().IsRegular() {
+			keys[key] = struct{}{}
+		}
+	}
+	return &eventLog{
+		dir:        dir,
+		maxEntries: maxEntries,
+		keys:       keys,
+		holders:    make(map[string]int),
+		now:        time.Now,
+	}, nil
+}
+
+// eventLogKey identifies an event in the log. CloudEvents are unique by
+// source and ID.
+func eventLogKey(event cloudevents.Event) string {
+	sum := sha256.Sum256([]byte(event.Source() + "\x00" + event.ID()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *eventLog) path(key string) string {
+	return filepath.Join(l.dir, key+eventLogFileSuffix)
+}
+
+// append writes the event to the log and holds its entry for the delivery
+// being processed, returning the key to release it with
+func (l *eventLog) append(event cloudevents.Event) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := eventLogKey(event)
+	if _, exists := l.keys[key]; !exists && l.maxEntries > 0 && len(l.keys) >= l.maxEntries {
+		return "", errEventLogFull
+	}
+
+	data, err := json.Marshal(eventLogEntry{Received: l.now(), Event: event})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event %s: %w", event.ID(), err)
+	}
+
+	// Write to a temporary file and rename it into place so a crash can't
+	// leave a partially written entry behind
+	tmp, err := os.CreateTemp(l.dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create event log entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write event log entry: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to sync event log entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close event log entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path(key)); err != nil {
+		return "", fmt.Errorf("failed to commit event log entry: %w", err)
+	}
+
+	l.keys[key] = struct{}{}
+	l.holders[key]++
+	return key, nil
+}
+
+// release drops a delivery's hold on an entry. If the delivery was processed
+// the entry is removed, unless another delivery of the event still holds it.
+// A failed delivery leaves the entry to be replayed.
+func (l *eventLog) release(key string, processed bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.holders[key]--; l.holders[key] <= 0 {
+		delete(l.holders, key)
+	}
+	if !processed {
+		return nil
+	}
+	return l.discardLocked(key)
+}
+
+// discard removes an event from the log, unless a delivery of it is being
+// processed
+func (l *eventLog) discard(key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.discardLocked(key)
+}
+
+// discardLocked implements discard, the caller must hold the lock
+func (l *eventLog) discardLocked(key string) error {
+	if l.holders[key] > 0 {
+		return nil
+	}
+	if err := os.Remove(l.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove event log entry: %w", err)
+	}
+	delete(l.keys, key)
+	return nil
+}
+
+// pending returns the events in the log, oldest first
+func (l *eventLog) pending() ([]cloudevents.Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]eventLogEntry, 0, len(l.keys))
+	for key := range l.keys {
+		data, err := os.ReadFile(l.path(key))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event log entry: %w", err)
+		}
+		var entry eventLogEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode event log entry %s: %w", key, err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Received.Before(entries[j].Received)
+	})
+
+	events := make([]cloudevents.Event, len(entries))
+	for i, entry := range entries {
+		events[i] = entry.Event
+	}
+	return events, nil
+}
+
+// len returns the number of events in the log
+func (l *eventLog) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.keys)
+}
+
+// useEventLog makes the service write events to the log while processing
+// them, and replays any events left in the log by a previous run. It must be
+// called before the service starts receiving events.
+func (s *Service) useEventLog(log *eventLog) {
+	s.eventLog = log
+	s.runInBackground(s.replayEventLog)
+}
+
+// replayEventLog processes the events left in the log. Events that fail again
+// stay in the log for the next startup.
+func (s *Service) replayEventLog(ctx context.Context) {
+	events, err := s.eventLog.pending()
+	if err != nil {
+		s.logger.Error(err, "Failed to read event log")
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	s.logger.Info("Replaying events from event log", gozap.Int("count", len(events)))
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.handleCloudEvent(ctx, event); err != nil {
+			s.logger.Error(err, "Failed to replay event", gozap.String("id", event.ID()))
+			continue
+		}
+		// Events that are now ignored never reach the log handling in
+		// handleCloudEvent, so they're removed here
+		if err := s.eventLog.discard(eventLogKey(event)); err != nil {
+			s.logger.Error(err, "Failed to remove event from event log", gozap.String("id", event.ID()))
+		}
+	}
+}
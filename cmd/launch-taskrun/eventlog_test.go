@@ -0,0 +1,257 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newIgnoredEvent returns an event for a resource the service doesn't
+// process, so handling it succeeds without any API calls
+func newIgnoredEvent(t *testing.T, id string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetSource("test-source")
+	event.SetType(apiServerAddEventType)
+	require.NoError(t, event.SetData(cloudevents.ApplicationJSON, map[string]string{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+	}))
+	return event
+}
+
+func TestEventLog_Append(t *testing.T) {
+	dir := t.TempDir()
+	log, err := newEventLog(dir, 10)
+	require.NoError(t, err)
+
+	now := time.Now()
+	log.now = func() time.Time { return now }
+	first, err := log.append(newSnapshotEvent(t, "1", "snapshot-1"))
+	require.NoError(t, err)
+	now = now.Add(time.Second)
+	_, err = log.append(newSnapshotEvent(t, "2", "snapshot-2"))
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, 2, log.len())
+
+	// Redelivery of the same event replaces its entry
+	now = now.Add(time.Second)
+	again, err := log.append(newSnapshotEvent(t, "1", "snapshot-1"))
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.Equal(t, 2, log.len())
+
+	events, err := log.pending()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "2", events[0].ID())
+	assert.Equal(t, "1", events[1].ID())
+
+	var data CloudEventData
+	require.NoError(t, events[1].DataAs(&data))
+	assert.Equal(t, "snapshot-1", data.Metadata.Name)
+}
+
+func TestEventLog_Bounded(t *testing.T) {
+	log, err := newEventLog(t.TempDir(), 2)
+	require.NoError(t, err)
+
+	_, err = log.append(newSnapshotEvent(t, "1", "snapshot-1"))
+	require.NoError(t, err)
+	key, err := log.append(newSnapshotEvent(t, "2", "snapshot-2"))
+	require.NoError(t, err)
+	require.NoError(t, log.release(key, false))
+
+	_, err = log.append(newSnapshotEvent(t, "3", "snapshot-3"))
+	assert.ErrorIs(t, err, errEventLogFull)
+
+	// Existing entries can still be rewritten
+	key, err = log.append(newSnapshotEvent(t, "2", "snapshot-2"))
+	require.NoError(t, err)
+
+	require.NoError(t, log.release(key, true))
+	_, err = log.append(newSnapshotEvent(t, "3", "snapshot-3"))
+	assert.NoError(t, err)
+}
+
+func TestEventLog_ConcurrentDeliveries(t *testing.T) {
+	log, err := newEventLog(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	// Two copies of the same event are being processed
+	first, err := log.append(newSnapshotEvent(t, "1", "snapshot-1"))
+	require.NoError(t, err)
+	second, err := log.append(newSnapshotEvent(t, "1", "snapshot-1"))
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	// Neither the first copy finishing nor a dead-letter removes the entry
+	// the second copy still holds
+	require.NoError(t, log.release(first, true))
+	require.NoError(t, log.discard(first))
+	events, err := log.pending()
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// A failed copy leaves the entry to be replayed
+	require.NoError(t, log.release(second, false))
+	events, err = log.pending()
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	require.NoError(t, log.discard(second))
+	events, err = log.pending()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, 0, log.len())
+}
+
+func TestEventLog_ReopenFindsEntries(t *testing.T) {
+	dir := t.TempDir()
+	log, err := newEventLog(dir, 10)
+	require.NoError(t, err)
+	_, err = log.append(newSnapshotEvent(t, "1", "snapshot-1"))
+	require.NoError(t, err)
+
+	// Leftover temporary files aren't entries
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tmp-123"), []byte("partial"), 0o600))
+
+	reopened, err := newEventLog(dir, 10)
+	require.NoError(t, err)
+	events, err := reopened.pending()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "1", events[0].ID())
+}
+
+func TestEventLog_ReplayOnStartup(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := newEventLog(dir, 10)
+	require.NoError(t, err)
+	_, err = previous.append(newIgnoredEvent(t, "1"))
+	require.NoError(t, err)
+	_, err = previous.append(newIgnoredEvent(t, "2"))
+	require.NoError(t, err)
+
+	log, err := newEventLog(dir, 10)
+	require.NoError(t, err)
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	defer service.Close()
+
+	service.useEventLog(log)
+
+	assert.Eventually(t, func() bool {
+		return log.len() == 0
+	}, time.Second, 5*time.Millisecond)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestHandleCloudEvent_EventLog(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "test-namespace")
+
+	t.Run("removed after success", func(t *testing.T) {
+		log, err := newEventLog(t.TempDir(), 10)
+		require.NoError(t, err)
+
+		mockK8s := &mockK8sClient{}
+		mockTekton := &mockTektonClient{}
+		mockCrtlClient := &mockControllerRuntimeClient{}
+		service := NewServiceWithDependencies(mockK8s, mockTekton, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+		defer service.Close()
+		service.eventLog = log
+
+		setupConfigMapMock(mockK8s, "test-namespace", map[string]string{
+			"TASK_NAME":      "generate-vsa",
+			"VSA_UPLOAD_URL": "https://test-upload.example.com",
+		})
+		setupSuccessfulECPLookupMocks(mockCrtlClient, "test-application", "test-namespace", "test-target")
+		mockTektonV1 := &mockTektonV1{}
+		mockTaskRunCreator := &mockTektonTaskRunCreator{}
+		mockTaskRunCreator.On("Create", mock.Anything, mock.Anything, metav1.CreateOptions{}).Run(func(mock.Arguments) {
+			// The event is logged while the TaskRun is being created
+			assert.Equal(t, 1, log.len())
+		}).Return(&tektonv1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "test-taskrun", Namespace: "test-namespace"}}, nil)
+		mockTektonV1.On("TaskRuns", "test-namespace").Return(mockTaskRunCreator)
+		mockTekton.On("TektonV1").Return(mockTektonV1)
+
+		err = service.handleCloudEvent(context.Background(), newSnapshotEvent(t, "1", "test-snapshot"))
+
+		assert.NoError(t, err)
+		mockTaskRunCreator.AssertExpectations(t)
+		assert.Equal(t, 0, log.len())
+	})
+
+	t.Run("kept after failure", func(t *testing.T) {
+		log, err := newEventLog(t.TempDir(), 10)
+		require.NoError(t, err)
+
+		mockK8s := &mockK8sClient{}
+		service := NewServiceWithDependencies(mockK8s, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+		defer service.Close()
+		service.eventLog = log
+
+		mockConfigMapGetter := &mockK8sConfigMapGetter{}
+		mockConfigMapGetter.On("Get", mock.Anything, "taskrun-config", metav1.GetOptions{}).Return((*corev1.ConfigMap)(nil), fmt.Errorf("configmap not found"))
+		mockCoreV1 := &mockK8sCoreV1{}
+		mockCoreV1.On("ConfigMaps", "test-namespace").Return(mockConfigMapGetter)
+		mockK8s.On("CoreV1").Return(mockCoreV1)
+
+		event := newSnapshotEvent(t, "1", "test-snapshot")
+		err = service.handleCloudEvent(context.Background(), event)
+
+		assert.Error(t, err)
+		events, err := log.pending()
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, event.ID(), events[0].ID())
+	})
+
+	t.Run("full log rejects the event", func(t *testing.T) {
+		log, err := newEventLog(t.TempDir(), 1)
+		require.NoError(t, err)
+		_, err = log.append(newSnapshotEvent(t, "other", "other-snapshot"))
+		require.NoError(t, err)
+
+		service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+		defer service.Close()
+		service.eventLog = log
+
+		err = service.handleCloudEvent(context.Background(), newSnapshotEvent(t, "1", "test-snapshot"))
+
+		assert.ErrorIs(t, err, errEventLogFull)
+	})
+}
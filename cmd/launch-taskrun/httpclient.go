@@ -0,0 +1,43 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// defaultOutboundTimeout bounds an outbound request when
+// OUTBOUND_HTTP_TIMEOUT_SECONDS isn't set
+const defaultOutboundTimeout = 30 * time.Second
+
+// newOutboundHTTPClient returns the client for the requests the service
+// makes, such as delivering events to a sink. Requests go through the proxy
+// set in HTTP_PROXY or HTTPS_PROXY unless NO_PROXY excludes them. Unlike
+// http.ProxyFromEnvironment, the variables are read when the client is built
+// rather than once per process.
+func newOutboundHTTPClient(timeout time.Duration) *http.Client {
+	proxy := httpproxy.FromEnvironment().ProxyFunc()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(r *http.Request) (*url.URL, error) {
+		return proxy(r.URL)
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
@@ -0,0 +1,84 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setProxyEnv sets both spellings of the proxy variables so the
+// environment the tests run in doesn't leak in
+func setProxyEnv(t *testing.T, httpProxy, noProxy string) {
+	t.Setenv("HTTP_PROXY", httpProxy)
+	t.Setenv("http_proxy", httpProxy)
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+	t.Setenv("NO_PROXY", noProxy)
+	t.Setenv("no_proxy", noProxy)
+}
+
+func TestNewOutboundHTTPClient_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A proxy is sent the absolute URL of the request
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer proxy.Close()
+
+	setProxyEnv(t, proxy.URL, "internal.example.com")
+	client := newOutboundHTTPClient(5 * time.Second)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+
+	t.Run("events are sent through the proxy", func(t *testing.T) {
+		sender, err := NewEventSender("http://sink.example.com/events", client)
+		require.NoError(t, err)
+		event := cloudevents.NewEvent()
+		event.SetType(taskRunCreatedEventType)
+		event.SetSource(taskRunCreatedEventSource)
+
+		require.NoError(t, sender.Send(context.Background(), event))
+		assert.Equal(t, []string{"http://sink.example.com/events"}, proxied)
+	})
+
+	t.Run("NO_PROXY hosts are reached directly", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "http://internal.example.com/events", nil)
+
+		proxyURL, err := client.Transport.(*http.Transport).Proxy(req)
+
+		require.NoError(t, err)
+		assert.Nil(t, proxyURL)
+	})
+
+	t.Run("read when the client is built", func(t *testing.T) {
+		setProxyEnv(t, "", "")
+		req := httptest.NewRequest(http.MethodPost, "http://sink.example.com/events", nil)
+
+		proxyURL, err := newOutboundHTTPClient(time.Second).Transport.(*http.Transport).Proxy(req)
+
+		require.NoError(t, err)
+		assert.Nil(t, proxyURL)
+	})
+}
@@ -17,6 +17,7 @@
 package k8s
 
 import (
+	"crypto/x509"
 	"fmt"
 	"os"
 
@@ -41,9 +42,45 @@ func NewK8sConfig() (*rest.Config, error) {
 			return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
 		}
 	}
+	if caFile := os.Getenv("KUBE_CA_BUNDLE_FILE"); caFile != "" {
+		if err := appendCABundle(k8sConfig, caFile); err != nil {
+			return nil, err
+		}
+	}
 	return k8sConfig, nil
 }
 
+// appendCABundle adds the certificates in a PEM file to the ones trusted for
+// the API server, for clusters whose API server certificate is signed by a CA
+// the config doesn't know about
+func appendCABundle(k8sConfig *rest.Config, caFile string) error {
+	bundle, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read KUBE_CA_BUNDLE_FILE: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(bundle) {
+		return fmt.Errorf("KUBE_CA_BUNDLE_FILE %s doesn't contain any PEM encoded certificates", caFile)
+	}
+	if k8sConfig.Insecure {
+		return fmt.Errorf("KUBE_CA_BUNDLE_FILE can't be used with a config that skips TLS verification")
+	}
+
+	// CAData takes precedence over CAFile, so the CA the config already
+	// trusts has to be moved into it
+	caData := k8sConfig.CAData
+	if len(caData) == 0 && k8sConfig.CAFile != "" {
+		if caData, err = os.ReadFile(k8sConfig.CAFile); err != nil {
+			return fmt.Errorf("failed to read CA file %s: %w", k8sConfig.CAFile, err)
+		}
+	}
+	if len(caData) > 0 && caData[len(caData)-1] != '\n' {
+		caData = append(caData, '\n')
+	}
+	k8sConfig.CAData = append(caData, bundle...)
+	k8sConfig.CAFile = ""
+	return nil
+}
+
 func NewControllerRuntimeClient() (client.Client, error) {
 	k8sConfig, err := NewK8sConfig()
 	if err != nil {
@@ -17,8 +17,17 @@
 package k8s
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -212,3 +221,105 @@ users:
 		assert.Nil(t, client)
 	})
 }
+
+// newTestCA returns a PEM encoded self-signed CA certificate
+func newTestCA(t *testing.T, name string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestNewK8sConfig_CABundle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+		return path
+	}
+	writeKubeconfig := func(name, clusterCA string) string {
+		return writeFile(name, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://test-cluster
+`+clusterCA+`  name: test
+contexts:
+- context:
+    cluster: test
+    user: test
+  name: test
+current-context: test
+users:
+- name: test
+  user: {}
+`))
+	}
+	clusterCA := newTestCA(t, "cluster-ca")
+	extraCA := newTestCA(t, "extra-ca")
+	extraCAFile := writeFile("extra-ca.pem", extraCA)
+	t.Setenv("HOME", "/non/existent/directory")
+
+	t.Run("added to a config without a CA", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", writeKubeconfig("no-ca", ""))
+		t.Setenv("KUBE_CA_BUNDLE_FILE", extraCAFile)
+
+		config, err := NewK8sConfig()
+
+		require.NoError(t, err)
+		assert.Equal(t, extraCA, config.CAData)
+	})
+
+	t.Run("appended to the config's CA", func(t *testing.T) {
+		clusterCAFile := writeFile("cluster-ca.pem", clusterCA)
+		t.Setenv("KUBECONFIG", writeKubeconfig("with-ca", "    certificate-authority: "+clusterCAFile+"\n"))
+		t.Setenv("KUBE_CA_BUNDLE_FILE", extraCAFile)
+
+		config, err := NewK8sConfig()
+
+		require.NoError(t, err)
+		assert.Empty(t, config.CAFile)
+		assert.Equal(t, string(clusterCA)+string(extraCA), string(config.CAData))
+		pool := x509.NewCertPool()
+		assert.True(t, pool.AppendCertsFromPEM(config.CAData))
+	})
+
+	t.Run("not PEM", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", writeKubeconfig("not-pem", ""))
+		t.Setenv("KUBE_CA_BUNDLE_FILE", writeFile("not-pem.pem", []byte("not a certificate")))
+
+		config, err := NewK8sConfig()
+
+		assert.Nil(t, config)
+		assert.ErrorContains(t, err, "doesn't contain any PEM encoded certificates")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", writeKubeconfig("missing", ""))
+		t.Setenv("KUBE_CA_BUNDLE_FILE", filepath.Join(dir, "missing.pem"))
+
+		_, err := NewK8sConfig()
+
+		assert.ErrorContains(t, err, "failed to read KUBE_CA_BUNDLE_FILE")
+	})
+
+	t.Run("insecure config", func(t *testing.T) {
+		kubeconfig := writeKubeconfig("insecure", "    insecure-skip-tls-verify: true\n")
+		t.Setenv("KUBECONFIG", kubeconfig)
+		t.Setenv("KUBE_CA_BUNDLE_FILE", extraCAFile)
+
+		_, err := NewK8sConfig()
+
+		assert.ErrorContains(t, err, "skips TLS verification")
+	})
+}
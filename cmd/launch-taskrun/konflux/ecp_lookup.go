@@ -18,8 +18,10 @@ package konflux
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	gozap "go.uber.org/zap"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -38,39 +40,119 @@ type Logger interface {
 	Error(err error, msg string, fields ...gozap.Field)
 }
 
-// findReleasePlan looks for a release plan applicable for a given application
-func FindReleasePlan(ctx context.Context, cli ClientReader, logger Logger, appName string, ns string) (ReleasePlan, error) {
+// ErrNoReleasePlan is returned when no ReleasePlan exists for the application
+var ErrNoReleasePlan = errors.New("no release plans found")
+
+// ErrAmbiguousReleasePlan is returned when several ReleasePlans match and the
+// lookup is configured to fail rather than pick one
+var ErrAmbiguousReleasePlan = errors.New("multiple release plans found")
+
+// AmbiguityMode controls what happens when more than one ReleasePlan matches
+type AmbiguityMode string
+
+const (
+	// AmbiguityFirst warns and uses the first matching ReleasePlan
+	AmbiguityFirst AmbiguityMode = "first"
+	// AmbiguityError fails the lookup with ErrAmbiguousReleasePlan
+	AmbiguityError AmbiguityMode = "error"
+)
+
+// LookupOptions tunes how the ReleasePlan for a snapshot is found. The zero
+// value gives the default behavior.
+type LookupOptions struct {
+	AmbiguityMode AmbiguityMode
+	// Target, when set, limits the lookup to ReleasePlans with that target
+	Target string
+	// SearchNamespaces are searched in order when the snapshot's namespace
+	// has no matching ReleasePlan
+	SearchNamespaces []string
+}
+
+// Equal reports whether both options give the same lookup
+func (o LookupOptions) Equal(other LookupOptions) bool {
+	return o.AmbiguityMode == other.AmbiguityMode && o.Target == other.Target &&
+		slices.Equal(o.SearchNamespaces, other.SearchNamespaces)
+}
+
+// FindReleasePlan looks for a release plan applicable for a given application
+// in the namespace, then in each of the options' SearchNamespaces, returning
+// the first found
+func FindReleasePlan(ctx context.Context, cli ClientReader, logger Logger, appName string, ns string, opts LookupOptions) (ReleasePlan, error) {
+	rp, err := findReleasePlanInNamespace(ctx, cli, logger, appName, ns, opts)
+	if len(opts.SearchNamespaces) == 0 || !errors.Is(err, ErrNoReleasePlan) {
+		return rp, err
+	}
+
+	searched := []string{ns}
+	for _, searchNs := range opts.SearchNamespaces {
+		if slices.Contains(searched, searchNs) {
+			continue
+		}
+		searched = append(searched, searchNs)
+		rp, err = findReleasePlanInNamespace(ctx, cli, logger, appName, searchNs, opts)
+		if !errors.Is(err, ErrNoReleasePlan) {
+			return rp, err
+		}
+		logger.Info("No ReleasePlan found, trying the next namespace", gozap.String("namespace", searchNs), gozap.Error(err))
+	}
+	if opts.Target != "" {
+		err = fmt.Errorf("%w for application name: %s with target: %s in namespaces: %s", ErrNoReleasePlan, appName, opts.Target, strings.Join(searched, ", "))
+	} else {
+		err = fmt.Errorf("%w for application name: %s in namespaces: %s", ErrNoReleasePlan, appName, strings.Join(searched, ", "))
+	}
+	return rp, &KonfluxError{Op: OpFindReleasePlan, Err: err}
+}
+
+// findReleasePlanInNamespace looks for a release plan applicable for a given
+// application in a single namespace
+func findReleasePlanInNamespace(ctx context.Context, cli ClientReader, logger Logger, appName string, ns string, opts LookupOptions) (ReleasePlan, error) {
 	var rp ReleasePlan
 
 	// Get all release plans in the namespace
 	planList := &ReleasePlanList{}
 	err := cli.List(ctx, planList, client.InNamespace(ns))
 	if err != nil {
-		return rp, fmt.Errorf("failed to lookup release plan in namespace %s: %w", ns, err)
+		return rp, &KonfluxError{Op: OpListReleasePlans, Namespace: ns, Err: err}
 	}
 	if len(planList.Items) == 0 {
-		return rp, fmt.Errorf("no release plans found in namespace %s", ns)
+		return rp, &KonfluxError{Op: OpFindReleasePlan, Namespace: ns, Err: ErrNoReleasePlan}
 	}
 
-	// Filter to find just the release plans for the given application
+	// Filter to find just the release plans for the given application, and
+	// the given target if there is one
 	var matchingPlans []ReleasePlan
 	for _, plan := range planList.Items {
-		if plan.Spec.Application == appName {
-			matchingPlans = append(matchingPlans, plan)
+		if plan.Spec.Application != appName {
+			continue
 		}
+		if opts.Target != "" && plan.Spec.Target != opts.Target {
+			continue
+		}
+		matchingPlans = append(matchingPlans, plan)
 	}
 	if len(matchingPlans) == 0 {
-		return rp, fmt.Errorf("no release plans found for application name: %s", appName)
+		if opts.Target != "" {
+			err = fmt.Errorf("%w for application name: %s with target: %s", ErrNoReleasePlan, appName, opts.Target)
+		} else {
+			err = fmt.Errorf("%w for application name: %s", ErrNoReleasePlan, appName)
+		}
+		return rp, &KonfluxError{Op: OpFindReleasePlan, Namespace: ns, Err: err}
 	}
 
 	if len(matchingPlans) > 1 {
 		// TODO: I'm expecting most of the time there will be only one ReleasePlan, but
 		// I'm not sure how correct that is. Could there be more than one? If there was
-		// more than one, how would we know which one to choose? For now we'll log a
+		// more than one, how would we know which one to choose? By default we'll log a
 		// warning with the details, and proceed with the first one found.
+		described := make([]string, 0, len(matchingPlans))
 		for _, plan := range matchingPlans {
 			rpa := fmt.Sprintf("%s/%s", plan.Spec.Target, plan.Labels["release.appstudio.openshift.io/releasePlanAdmission"])
 			logger.Warn("Found multiple ReleasePlans", gozap.String("RP", plan.Name), gozap.String("Related RPA", rpa))
+			described = append(described, fmt.Sprintf("%s (RPA %s)", plan.Name, rpa))
+		}
+		if opts.AmbiguityMode == AmbiguityError {
+			err = fmt.Errorf("%w for application name %s: %s", ErrAmbiguousReleasePlan, appName, strings.Join(described, ", "))
+			return rp, &KonfluxError{Op: OpFindReleasePlan, Namespace: ns, Err: err}
 		}
 	}
 	rp = matchingPlans[0]
@@ -97,39 +179,81 @@ func FindReleasePlanAdmission(ctx context.Context, cli ClientReader, logger Logg
 	}
 	err := cli.Get(ctx, rpaKey, &rpa)
 	if err != nil {
-		return rpa, fmt.Errorf("failed to get release plan admission %s/%s: %w", rpaKey.Namespace, rpaKey.Name, err)
+		return rpa, &KonfluxError{Op: OpGetReleasePlanAdmission, Namespace: rpaKey.Namespace, Name: rpaKey.Name, Err: err}
 	}
 	return rpa, nil
 }
 
+// ResolvedPolicy identifies the ECP found for a snapshot
+type ResolvedPolicy struct {
+	Namespace string
+	Name      string
+	// IsDefault is set when the RPA didn't specify a policy so the default was used
+	IsDefault bool
+	// PublicKeySecret is the public key secret set in the RPA, with the
+	// namespace and key filled in, or nil if the RPA doesn't set one
+	PublicKeySecret *SecretKeyReference
+	// SigningKeySecretName is the VSA signing key secret set in the RPA, or
+	// "" if the RPA doesn't set one
+	SigningKeySecretName string
+}
+
+// DefaultPublicKeySecretKey is the key read from an RPA's public key secret
+// when the reference doesn't name one
+const DefaultPublicKeySecretKey = "cosign.pub"
+
+// String returns the policy as "namespace/name", the format Conforma's --policy
+// flag expects
+func (p ResolvedPolicy) String() string {
+	if p.Namespace == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%s/%s", p.Namespace, p.Name)
+}
+
+// ParsePolicyRef splits a "namespace/name" policy reference. A reference
+// without a slash is treated as a bare name.
+func ParsePolicyRef(ref string) ResolvedPolicy {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		return ResolvedPolicy{Name: ref}
+	}
+	return ResolvedPolicy{Namespace: namespace, Name: name}
+}
+
 // FindECP takes a snapshot and tries to find the ECP that would be applicable in the
 // Konflux release pipeline if that snapshot was released by looking up the relevant RPA
 func FindEnterpriseContractPolicy(ctx context.Context, cli ClientReader, logger Logger, snapshot *Snapshot) (string, error) {
+	policy, err := ResolveEnterpriseContractPolicy(ctx, cli, logger, snapshot, LookupOptions{})
+	if err != nil {
+		return "", err
+	}
+	return policy.String(), nil
+}
+
+// ResolveEnterpriseContractPolicy is like FindEnterpriseContractPolicy but returns
+// the policy's parts rather than a formatted string
+func ResolveEnterpriseContractPolicy(ctx context.Context, cli ClientReader, logger Logger, snapshot *Snapshot, opts LookupOptions) (ResolvedPolicy, error) {
 	// TODO: There might be a way to look this up which would be preferable to hard-coding it here
 	const defaultEcpName = "registry-standard"
 
-	// Extract the application name from the raw JSON spec
-	var spec struct {
-		Application string `json:"application"`
-	}
-	if err := json.Unmarshal(snapshot.Spec, &spec); err != nil {
-		return "", fmt.Errorf("failed to unmarshal snapshot spec to extract application: %w", err)
+	appName, err := snapshot.ApplicationName()
+	if err != nil {
+		return ResolvedPolicy{}, err
 	}
-
-	appName := spec.Application
 	ns := snapshot.Namespace
 
 	// Find the applicable ReleasePlan for this application
-	rp, err := FindReleasePlan(ctx, cli, logger, appName, ns)
+	rp, err := FindReleasePlan(ctx, cli, logger, appName, ns, opts)
 	if err != nil {
-		return "", err
+		return ResolvedPolicy{}, err
 	}
 	logger.Info("Found ReleasePlan", gozap.String("name", rp.Name), gozap.String("namespace", rp.Namespace))
 
 	// Use the ReleasePlan to find the relevant ReleasePlanAdmission
 	rpa, err := FindReleasePlanAdmission(ctx, cli, logger, rp)
 	if err != nil {
-		return "", err
+		return ResolvedPolicy{}, err
 	}
 	logger.Info("Found ReleasePlanAdmission", gozap.String("name", rpa.Name), gozap.String("namespace", rpa.Namespace))
 
@@ -141,7 +265,8 @@ func FindEnterpriseContractPolicy(ctx context.Context, cli ClientReader, logger
 
 	// Fall back to the default value if the RPA doesn't set a policy
 	var logMsg string
-	if ecpName == "" {
+	isDefault := ecpName == ""
+	if isDefault {
 		ecpName = defaultEcpName
 		logMsg = "No policy specified in RPA, using default"
 	} else {
@@ -150,7 +275,32 @@ func FindEnterpriseContractPolicy(ctx context.Context, cli ClientReader, logger
 
 	logger.Info(logMsg, gozap.String("name", ecpName), gozap.String("namespace", ecpNamespace))
 
+	var publicKeySecret *SecretKeyReference
+	if ref := rpa.Spec.PublicKeySecret; ref != nil && ref.Name != "" {
+		publicKeySecret = &SecretKeyReference{Name: ref.Name, Namespace: ref.Namespace, Key: ref.Key}
+		if publicKeySecret.Namespace == "" {
+			publicKeySecret.Namespace = rpa.Namespace
+		}
+		if publicKeySecret.Key == "" {
+			publicKeySecret.Key = DefaultPublicKeySecretKey
+		}
+		logger.Info("Using public key secret specified in RPA",
+			gozap.String("name", publicKeySecret.Name),
+			gozap.String("namespace", publicKeySecret.Namespace),
+			gozap.String("key", publicKeySecret.Key))
+	}
+
+	if rpa.Spec.SigningKeySecret != "" {
+		logger.Info("Using signing key secret specified in RPA", gozap.String("name", rpa.Spec.SigningKeySecret))
+	}
+
 	// Example value: rhtap-releng-tenant/registry-rhtap-contract
 	// Conforma can use this directly with its --policy flag
-	return fmt.Sprintf("%s/%s", ecpNamespace, ecpName), nil
+	return ResolvedPolicy{
+		Namespace:            ecpNamespace,
+		Name:                 ecpName,
+		IsDefault:            isDefault,
+		PublicKeySecret:      publicKeySecret,
+		SigningKeySecretName: rpa.Spec.SigningKeySecret,
+	}, nil
 }
@@ -21,9 +21,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/conforma/knative-service/cmd/launch-taskrun/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	gozap "go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -149,9 +151,6 @@ func TestFindECP_DefaultPolicy(t *testing.T) {
 }
 
 func TestFindECP_NoReleasePlans(t *testing.T) {
-	scheme := runtime.NewScheme()
-	require.NoError(t, AddToScheme(scheme))
-
 	snapshot := &Snapshot{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test-snapshot",
@@ -160,16 +159,36 @@ func TestFindECP_NoReleasePlans(t *testing.T) {
 		Spec: json.RawMessage(`{"application":"test-app"}`),
 	}
 
-	cli := fake.NewClientBuilder().
-		WithScheme(scheme).
-		Build()
+	cli := (&testutil.FakeClientReader{}).QueueList(testutil.Response{Object: &ReleasePlanList{}})
 
 	logger := &mockLogger{t: t}
 
 	_, err := FindEnterpriseContractPolicy(context.Background(), cli, logger, snapshot)
 
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "no release plans found in namespace")
+	assert.Contains(t, err.Error(), "failed to find release plan in namespace test-ns: no release plans found")
+	assert.ErrorIs(t, err, ErrNoReleasePlan)
+	assert.Equal(t, 1, cli.ListCalls)
+}
+
+func TestFindECP_TransientListError(t *testing.T) {
+	snapshot := &Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "test-ns",
+		},
+		Spec: json.RawMessage(`{"application":"test-app"}`),
+	}
+	cli := (&testutil.FakeClientReader{}).QueueList(testutil.Response{Err: apierrors.NewServiceUnavailable("try again")})
+
+	_, err := FindEnterpriseContractPolicy(context.Background(), cli, &mockLogger{t: t}, snapshot)
+
+	// The lookup doesn't retry itself, callers see the cause to decide
+	var konfluxErr *KonfluxError
+	require.ErrorAs(t, err, &konfluxErr)
+	assert.Equal(t, OpListReleasePlans, konfluxErr.Op)
+	assert.True(t, apierrors.IsServiceUnavailable(err))
+	assert.Equal(t, 1, cli.ListCalls)
 }
 
 func TestFindECP_NoMatchingApplication(t *testing.T) {
@@ -207,6 +226,7 @@ func TestFindECP_NoMatchingApplication(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no release plans found for application name: test-app")
+	assert.ErrorIs(t, err, ErrNoReleasePlan)
 }
 
 func TestFindECP_RPANotFound(t *testing.T) {
@@ -247,3 +267,646 @@ func TestFindECP_RPANotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get release plan admission")
 }
+
+func TestResolveECP(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	releasePlan := &ReleasePlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-rp",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"release.appstudio.openshift.io/releasePlanAdmission": "test-rpa",
+			},
+		},
+		Spec: ReleasePlanSpec{
+			Application: "test-app",
+			Target:      "target-ns",
+		},
+	}
+
+	snapshot := &Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "test-ns",
+		},
+		Spec: json.RawMessage(`{"application":"test-app"}`),
+	}
+
+	tests := []struct {
+		name      string
+		rpaPolicy string
+		expected  ResolvedPolicy
+	}{
+		{
+			name:      "policy from RPA",
+			rpaPolicy: "custom-policy",
+			expected:  ResolvedPolicy{Namespace: "target-ns", Name: "custom-policy", IsDefault: false},
+		},
+		{
+			name:      "default policy",
+			rpaPolicy: "",
+			expected:  ResolvedPolicy{Namespace: "target-ns", Name: "registry-standard", IsDefault: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpa := &ReleasePlanAdmission{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-rpa",
+					Namespace: "target-ns",
+				},
+				Spec: ReleasePlanAdmissionSpec{
+					Policy: tt.rpaPolicy,
+				},
+			}
+
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(releasePlan, rpa).
+				Build()
+
+			policy, err := ResolveEnterpriseContractPolicy(context.Background(), cli, &mockLogger{t: t}, snapshot, LookupOptions{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+			assert.Equal(t, tt.expected.Namespace+"/"+tt.expected.Name, policy.String())
+		})
+	}
+}
+
+func TestResolvedPolicy_String(t *testing.T) {
+	assert.Equal(t, "ns/name", ResolvedPolicy{Namespace: "ns", Name: "name"}.String())
+	assert.Equal(t, "name", ResolvedPolicy{Name: "name"}.String())
+}
+
+func TestParsePolicyRef(t *testing.T) {
+	assert.Equal(t, ResolvedPolicy{Namespace: "ns", Name: "name"}, ParsePolicyRef("ns/name"))
+	assert.Equal(t, ResolvedPolicy{Name: "name"}, ParsePolicyRef("name"))
+}
+
+func TestSnapshotApplicationName(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expected    string
+		expectedErr string
+	}{
+		{name: "application", spec: `{"application":"app"}`, expected: "app"},
+		{name: "applicationName", spec: `{"applicationName":"app"}`, expected: "app"},
+		{name: "both prefers application", spec: `{"application":"app","applicationName":"other"}`, expected: "app"},
+		{name: "empty application falls back", spec: `{"application":"","applicationName":"app"}`, expected: "app"},
+		{name: "neither", spec: `{"components":[]}`, expected: ""},
+		{name: "invalid spec", spec: `not json`, expectedErr: "failed to unmarshal snapshot spec"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := &Snapshot{Spec: json.RawMessage(tt.spec)}
+
+			appName, err := snapshot.ApplicationName()
+
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, appName)
+		})
+	}
+}
+
+func TestFindECP_ApplicationNameKey(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	releasePlan := &ReleasePlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-rp",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"release.appstudio.openshift.io/releasePlanAdmission": "test-rpa",
+			},
+		},
+		Spec: ReleasePlanSpec{
+			Application: "test-app",
+			Target:      "target-ns",
+		},
+	}
+
+	rpa := &ReleasePlanAdmission{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-rpa",
+			Namespace: "target-ns",
+		},
+		Spec: ReleasePlanAdmissionSpec{
+			Policy: "custom-policy",
+		},
+	}
+
+	snapshot := &Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "test-ns",
+		},
+		Spec: json.RawMessage(`{"applicationName":"test-app"}`),
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(releasePlan, rpa).
+		Build()
+
+	ecp, err := FindEnterpriseContractPolicy(context.Background(), cli, &mockLogger{t: t}, snapshot)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "target-ns/custom-policy", ecp)
+}
+
+func TestParseSnapshotSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expected    *SnapshotSpec
+		expectedErr string
+	}{
+		{
+			name: "application and components",
+			spec: `{"application":"app","components":[{"name":"c1","containerImage":"img1"},{"name":"c2","containerImage":"img2"}]}`,
+			expected: &SnapshotSpec{
+				Application: "app",
+				Components: []SnapshotComponent{
+					{Name: "c1", ContainerImage: "img1"},
+					{Name: "c2", ContainerImage: "img2"},
+				},
+			},
+		},
+		{
+			name: "component source",
+			spec: `{"application":"app","components":[{"name":"c1","containerImage":"img1","source":{"git":{"url":"https://github.com/org/c1","revision":"abc123"}}},{"name":"c2","containerImage":"img2"}]}`,
+			expected: &SnapshotSpec{
+				Application: "app",
+				Components: []SnapshotComponent{
+					{Name: "c1", ContainerImage: "img1", Source: &ComponentSource{Git: &GitSource{URL: "https://github.com/org/c1", Revision: "abc123"}}},
+					{Name: "c2", ContainerImage: "img2"},
+				},
+			},
+		},
+		{
+			name:     "applicationName fallback",
+			spec:     `{"applicationName":"app"}`,
+			expected: &SnapshotSpec{Application: "app"},
+		},
+		{
+			name:     "unknown fields are ignored",
+			spec:     `{"application":"app","artifacts":{}}`,
+			expected: &SnapshotSpec{Application: "app"},
+		},
+		{
+			name:     "display name and description",
+			spec:     `{"application":"app","displayName":"snap","displayDescription":"nightly build"}`,
+			expected: &SnapshotSpec{Application: "app", DisplayName: "snap", DisplayDescription: "nightly build"},
+		},
+		{
+			name:     "component without image",
+			spec:     `{"application":"app","components":[{"name":"c1"}]}`,
+			expected: &SnapshotSpec{Application: "app", Components: []SnapshotComponent{{Name: "c1"}}},
+		},
+		{
+			name:     "null spec",
+			spec:     `null`,
+			expected: &SnapshotSpec{},
+		},
+		{
+			name:        "empty spec",
+			spec:        ``,
+			expectedErr: "failed to unmarshal snapshot spec",
+		},
+		{
+			name:        "invalid JSON",
+			spec:        `{"application":`,
+			expectedErr: "failed to unmarshal snapshot spec",
+		},
+		{
+			name:        "components of the wrong type",
+			spec:        `{"application":"app","components":"c1"}`,
+			expectedErr: "failed to unmarshal snapshot spec",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseSnapshotSpec(json.RawMessage(tt.spec))
+
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				assert.Nil(t, spec)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, spec)
+		})
+	}
+}
+
+func TestNormalizeComponentImageField(t *testing.T) {
+	tests := []struct {
+		name        string
+		field       string
+		spec        string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "default field is left alone",
+			spec:     `{"application":"app","components":[{"name":"c1","image":"other","containerImage":"img1"}]}`,
+			expected: `{"application":"app","components":[{"name":"c1","image":"other","containerImage":"img1"}]}`,
+		},
+		{
+			name:     "explicit default field is left alone",
+			field:    DefaultComponentImageField,
+			spec:     `{"application":"app","components":[{"name":"c1","containerImage":"img1"}]}`,
+			expected: `{"application":"app","components":[{"name":"c1","containerImage":"img1"}]}`,
+		},
+		{
+			name:     "alternate field",
+			field:    "imageRef",
+			spec:     `{"application":"app","components":[{"name":"c1","imageRef":"img1","source":{"git":{"url":"https://github.com/org/c1"}}},{"name":"c2","containerImage":"img2"}]}`,
+			expected: `{"application":"app","components":[{"containerImage":"img1","imageRef":"img1","name":"c1","source":{"git":{"url":"https://github.com/org/c1"}}},{"containerImage":"img2","name":"c2"}]}`,
+		},
+		{
+			name:     "alternate field wins over containerImage",
+			field:    "image",
+			spec:     `{"components":[{"name":"c1","image":"img1","containerImage":"stale"}]}`,
+			expected: `{"components":[{"containerImage":"img1","image":"img1","name":"c1"}]}`,
+		},
+		{
+			name:     "no components",
+			field:    "image",
+			spec:     `{"application":"app"}`,
+			expected: `{"application":"app"}`,
+		},
+		{
+			name:        "invalid JSON",
+			field:       "image",
+			spec:        `{"application":`,
+			expectedErr: "failed to unmarshal snapshot spec",
+		},
+		{
+			name:        "components of the wrong type",
+			field:       "image",
+			spec:        `{"components":"c1"}`,
+			expectedErr: "failed to unmarshal snapshot components",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := NormalizeComponentImageField(json.RawMessage(tt.spec), tt.field)
+
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(normalized))
+		})
+	}
+}
+
+func TestResolveECP_PublicKeySecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	releasePlan := &ReleasePlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-rp",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"release.appstudio.openshift.io/releasePlanAdmission": "test-rpa",
+			},
+		},
+		Spec: ReleasePlanSpec{
+			Application: "test-app",
+			Target:      "target-ns",
+		},
+	}
+
+	snapshot := &Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "test-ns",
+		},
+		Spec: json.RawMessage(`{"application":"test-app"}`),
+	}
+
+	tests := []struct {
+		name     string
+		ref      *SecretKeyReference
+		expected *SecretKeyReference
+	}{
+		{
+			name:     "not set",
+			ref:      nil,
+			expected: nil,
+		},
+		{
+			name:     "name only defaults namespace and key",
+			ref:      &SecretKeyReference{Name: "rpa-key"},
+			expected: &SecretKeyReference{Name: "rpa-key", Namespace: "target-ns", Key: DefaultPublicKeySecretKey},
+		},
+		{
+			name:     "fully specified",
+			ref:      &SecretKeyReference{Name: "rpa-key", Namespace: "keys-ns", Key: "key.pub"},
+			expected: &SecretKeyReference{Name: "rpa-key", Namespace: "keys-ns", Key: "key.pub"},
+		},
+		{
+			name:     "empty name is ignored",
+			ref:      &SecretKeyReference{Key: "key.pub"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpa := &ReleasePlanAdmission{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-rpa",
+					Namespace: "target-ns",
+				},
+				Spec: ReleasePlanAdmissionSpec{
+					Policy:          "custom-policy",
+					PublicKeySecret: tt.ref,
+				},
+			}
+
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(releasePlan, rpa).
+				Build()
+
+			policy, err := ResolveEnterpriseContractPolicy(context.Background(), cli, &mockLogger{t: t}, snapshot, LookupOptions{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, policy.PublicKeySecret)
+			assert.Equal(t, "target-ns/custom-policy", policy.String())
+		})
+	}
+}
+
+func TestResolveECP_SigningKeySecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	releasePlan := &ReleasePlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-rp",
+			Namespace: "test-ns",
+			Labels: map[string]string{
+				"release.appstudio.openshift.io/releasePlanAdmission": "test-rpa",
+			},
+		},
+		Spec: ReleasePlanSpec{
+			Application: "test-app",
+			Target:      "target-ns",
+		},
+	}
+	snapshot := &Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-snapshot",
+			Namespace: "test-ns",
+		},
+		Spec: json.RawMessage(`{"application":"test-app"}`),
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "not set", secret: ""},
+		{name: "set", secret: "rpa-signing-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpa := &ReleasePlanAdmission{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-rpa",
+					Namespace: "target-ns",
+				},
+				Spec: ReleasePlanAdmissionSpec{
+					Policy:           "custom-policy",
+					SigningKeySecret: tt.secret,
+				},
+			}
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(releasePlan, rpa).
+				Build()
+
+			policy, err := ResolveEnterpriseContractPolicy(context.Background(), cli, &mockLogger{t: t}, snapshot, LookupOptions{})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.secret, policy.SigningKeySecretName)
+		})
+	}
+}
+
+func TestFindReleasePlan_AmbiguityMode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	newPlan := func(name, rpa string) *ReleasePlan {
+		return &ReleasePlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+				Labels: map[string]string{
+					"release.appstudio.openshift.io/releasePlanAdmission": rpa,
+				},
+			},
+			Spec: ReleasePlanSpec{
+				Application: "test-app",
+				Target:      "target-ns",
+			},
+		}
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(newPlan("rp-a", "rpa-a"), newPlan("rp-b", "rpa-b")).
+		Build()
+
+	t.Run("first", func(t *testing.T) {
+		for _, mode := range []AmbiguityMode{"", AmbiguityFirst} {
+			rp, err := FindReleasePlan(context.Background(), cli, &mockLogger{t: t}, "test-app", "test-ns", LookupOptions{AmbiguityMode: mode})
+
+			assert.NoError(t, err)
+			assert.Equal(t, "rp-a", rp.Name)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := FindReleasePlan(context.Background(), cli, &mockLogger{t: t}, "test-app", "test-ns", LookupOptions{AmbiguityMode: AmbiguityError})
+
+		assert.ErrorIs(t, err, ErrAmbiguousReleasePlan)
+		assert.ErrorContains(t, err, "rp-a (RPA target-ns/rpa-a)")
+		assert.ErrorContains(t, err, "rp-b (RPA target-ns/rpa-b)")
+	})
+
+	t.Run("error with a single match", func(t *testing.T) {
+		single := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(newPlan("rp-a", "rpa-a")).
+			Build()
+
+		rp, err := FindReleasePlan(context.Background(), single, &mockLogger{t: t}, "test-app", "test-ns", LookupOptions{AmbiguityMode: AmbiguityError})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "rp-a", rp.Name)
+	})
+}
+
+func TestFindReleasePlan_Target(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	newPlan := func(name, target string) *ReleasePlan {
+		return &ReleasePlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+			},
+			Spec: ReleasePlanSpec{
+				Application: "test-app",
+				Target:      target,
+			},
+		}
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(newPlan("rp-prod", "prod-ns"), newPlan("rp-stage", "stage-ns")).
+		Build()
+
+	tests := []struct {
+		name        string
+		target      string
+		expectedRP  string
+		expectedErr string
+	}{
+		{name: "no target uses the first plan", target: "", expectedRP: "rp-prod"},
+		{name: "matches prod", target: "prod-ns", expectedRP: "rp-prod"},
+		{name: "matches stage", target: "stage-ns", expectedRP: "rp-stage"},
+		{name: "unknown target", target: "other-ns", expectedErr: "failed to find release plan in namespace test-ns: no release plans found for application name: test-app with target: other-ns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp, err := FindReleasePlan(context.Background(), cli, &mockLogger{t: t}, "test-app", "test-ns", LookupOptions{Target: tt.target})
+
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrNoReleasePlan)
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRP, rp.Name)
+		})
+	}
+}
+
+func TestFindReleasePlan_SearchNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+
+	newPlan := func(name, namespace, application string) *ReleasePlan {
+		return &ReleasePlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: ReleasePlanSpec{
+				Application: application,
+				Target:      "target-ns",
+			},
+		}
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			newPlan("rp-other-app", "test-ns", "other-app"),
+			newPlan("rp-tenant", "tenant-ns", "test-app"),
+			newPlan("rp-shared", "shared-ns", "test-app"),
+			newPlan("rp-local", "local-ns", "local-app"),
+		).
+		Build()
+
+	tests := []struct {
+		name             string
+		application      string
+		searchNamespaces []string
+		expectedRP       string
+		expectedNs       string
+		expectedErr      string
+	}{
+		{
+			name:        "only the snapshot namespace by default",
+			application: "test-app",
+			expectedErr: "failed to find release plan in namespace test-ns: no release plans found for application name: test-app",
+		},
+		{
+			name:             "found only in a secondary namespace",
+			application:      "test-app",
+			searchNamespaces: []string{"empty-ns", "tenant-ns"},
+			expectedRP:       "rp-tenant",
+			expectedNs:       "tenant-ns",
+		},
+		{
+			name:             "first match in search order wins",
+			application:      "test-app",
+			searchNamespaces: []string{"shared-ns", "tenant-ns"},
+			expectedRP:       "rp-shared",
+			expectedNs:       "shared-ns",
+		},
+		{
+			name:             "not found anywhere",
+			application:      "missing-app",
+			searchNamespaces: []string{"tenant-ns", "test-ns"},
+			expectedErr:      "failed to find release plan: no release plans found for application name: missing-app in namespaces: test-ns, tenant-ns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp, err := FindReleasePlan(context.Background(), cli, &mockLogger{t: t}, tt.application, "test-ns", LookupOptions{SearchNamespaces: tt.searchNamespaces})
+
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, ErrNoReleasePlan)
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedRP, rp.Name)
+			assert.Equal(t, tt.expectedNs, rp.Namespace)
+		})
+	}
+
+	t.Run("the snapshot namespace is searched first", func(t *testing.T) {
+		rp, err := FindReleasePlan(context.Background(), cli, &mockLogger{t: t}, "local-app", "local-ns", LookupOptions{SearchNamespaces: []string{"tenant-ns"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "rp-local", rp.Name)
+	})
+}
+
+func TestLookupOptions_Equal(t *testing.T) {
+	opts := LookupOptions{AmbiguityMode: AmbiguityFirst, Target: "prod-ns", SearchNamespaces: []string{"tenant-ns"}}
+
+	assert.True(t, opts.Equal(LookupOptions{AmbiguityMode: AmbiguityFirst, Target: "prod-ns", SearchNamespaces: []string{"tenant-ns"}}))
+	assert.False(t, opts.Equal(LookupOptions{AmbiguityMode: AmbiguityFirst, Target: "prod-ns"}))
+	assert.False(t, opts.Equal(LookupOptions{AmbiguityMode: AmbiguityError, Target: "prod-ns", SearchNamespaces: []string{"tenant-ns"}}))
+}
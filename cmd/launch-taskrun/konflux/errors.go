@@ -0,0 +1,56 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package konflux
+
+import "fmt"
+
+// Operations reported in a KonfluxError
+const (
+	OpListReleasePlans        = "list release plans"
+	OpFindReleasePlan         = "find release plan"
+	OpGetReleasePlanAdmission = "get release plan admission"
+	OpGetSecret               = "get secret"
+)
+
+// KonfluxError is returned by the lookups in this package. It records which
+// operation failed and on what, so callers can tell the steps of a lookup
+// apart with errors.As. Sentinel errors such as ErrNoReleasePlan are still
+// matched with errors.Is.
+type KonfluxError struct {
+	// Op is the operation that failed, one of the Op constants
+	Op string
+	// Namespace is empty when the operation spanned several namespaces
+	Namespace string
+	// Name is empty when the operation was on a whole namespace
+	Name string
+	Err  error
+}
+
+func (e *KonfluxError) Error() string {
+	switch {
+	case e.Name != "":
+		return fmt.Sprintf("failed to %s %s/%s: %v", e.Op, e.Namespace, e.Name, e.Err)
+	case e.Namespace != "":
+		return fmt.Sprintf("failed to %s in namespace %s: %v", e.Op, e.Namespace, e.Err)
+	default:
+		return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+	}
+}
+
+func (e *KonfluxError) Unwrap() error {
+	return e.Err
+}
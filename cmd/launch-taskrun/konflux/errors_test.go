@@ -0,0 +1,133 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package konflux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestKonfluxError_Error(t *testing.T) {
+	cause := errors.New("boom")
+
+	assert.EqualError(t, &KonfluxError{Op: OpGetSecret, Namespace: "ns", Name: "name", Err: cause}, "failed to get secret ns/name: boom")
+	assert.EqualError(t, &KonfluxError{Op: OpListReleasePlans, Namespace: "ns", Err: cause}, "failed to list release plans in namespace ns: boom")
+	assert.EqualError(t, &KonfluxError{Op: OpFindReleasePlan, Err: cause}, "failed to find release plan: boom")
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", &KonfluxError{Op: OpGetSecret, Err: cause}), cause)
+}
+
+func TestKonfluxError_As(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	plan := &ReleasePlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-rp",
+			Namespace: "test-ns",
+			Labels:    map[string]string{"release.appstudio.openshift.io/releasePlanAdmission": "missing-rpa"},
+		},
+		Spec: ReleasePlanSpec{Application: "test-app", Target: "target-ns"},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(plan).Build()
+	failingList := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, client client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			return errors.New("connection refused")
+		},
+	}).Build()
+	logger := &mockLogger{t: t}
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected KonfluxError
+		is       func(error) bool
+	}{
+		{
+			name: "listing release plans",
+			call: func() error {
+				_, err := FindReleasePlan(context.Background(), failingList, logger, "test-app", "test-ns", LookupOptions{})
+				return err
+			},
+			expected: KonfluxError{Op: OpListReleasePlans, Namespace: "test-ns"},
+		},
+		{
+			name: "no release plan",
+			call: func() error {
+				_, err := FindReleasePlan(context.Background(), cli, logger, "other-app", "test-ns", LookupOptions{})
+				return err
+			},
+			expected: KonfluxError{Op: OpFindReleasePlan, Namespace: "test-ns"},
+			is:       func(err error) bool { return errors.Is(err, ErrNoReleasePlan) },
+		},
+		{
+			name: "no release plan in any search namespace",
+			call: func() error {
+				_, err := FindReleasePlan(context.Background(), cli, logger, "other-app", "test-ns", LookupOptions{SearchNamespaces: []string{"tenant-ns"}})
+				return err
+			},
+			expected: KonfluxError{Op: OpFindReleasePlan},
+			is:       func(err error) bool { return errors.Is(err, ErrNoReleasePlan) },
+		},
+		{
+			name: "getting the release plan admission",
+			call: func() error {
+				_, err := FindReleasePlanAdmission(context.Background(), cli, logger, *plan)
+				return err
+			},
+			expected: KonfluxError{Op: OpGetReleasePlanAdmission, Namespace: "target-ns", Name: "missing-rpa"},
+			is:       apierrors.IsNotFound,
+		},
+		{
+			name: "getting a secret",
+			call: func() error {
+				_, err := FindSecretValue(context.Background(), cli, "test-ns", "missing", "key")
+				return err
+			},
+			expected: KonfluxError{Op: OpGetSecret, Namespace: "test-ns", Name: "missing"},
+			is:       apierrors.IsNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Callers usually wrap the error further
+			err := fmt.Errorf("lookup failed: %w", tt.call())
+
+			var konfluxErr *KonfluxError
+			require.ErrorAs(t, err, &konfluxErr)
+			assert.Equal(t, tt.expected.Op, konfluxErr.Op)
+			assert.Equal(t, tt.expected.Namespace, konfluxErr.Namespace)
+			assert.Equal(t, tt.expected.Name, konfluxErr.Name)
+			if tt.is != nil {
+				assert.True(t, tt.is(err), "the cause should still be matched: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package konflux
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FindSecretValue reads the value of a single key from a Secret. It's used for
+// config values that shouldn't live in a ConfigMap, e.g. URLs with embedded
+// credentials.
+func FindSecretValue(ctx context.Context, cli ClientReader, namespace, name, key string) (string, error) {
+	var secret corev1.Secret
+	secretKey := client.ObjectKey{
+		Namespace: namespace,
+		Name:      name,
+	}
+	err := cli.Get(ctx, secretKey, &secret)
+	if err != nil {
+		return "", &KonfluxError{Op: OpGetSecret, Namespace: namespace, Name: name, Err: err}
+	}
+
+	value, exists := secret.Data[key]
+	if !exists || len(value) == 0 {
+		return "", &KonfluxError{Op: OpGetSecret, Namespace: namespace, Name: name, Err: fmt.Errorf("key %s not found", key)}
+	}
+	return string(value), nil
+}
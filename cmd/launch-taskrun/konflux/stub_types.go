@@ -20,6 +20,7 @@ package konflux
 
 import (
 	"encoding/json"
+	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -48,6 +49,110 @@ func (r *Snapshot) DeepCopyObject() runtime.Object {
 	return out
 }
 
+// SnapshotSpec holds the parts of a Snapshot's spec the service uses
+type SnapshotSpec struct {
+	Application        string              `json:"application"`
+	DisplayName        string              `json:"displayName,omitempty"`
+	DisplayDescription string              `json:"displayDescription,omitempty"`
+	Components         []SnapshotComponent `json:"components"`
+}
+
+type SnapshotComponent struct {
+	Name           string `json:"name"`
+	ContainerImage string `json:"containerImage"`
+	// Source is where the component was built from, not every snapshot has it
+	Source *ComponentSource `json:"source,omitempty"`
+}
+
+// ComponentSource describes the source a component was built from
+type ComponentSource struct {
+	Git *GitSource `json:"git,omitempty"`
+}
+
+// GitSource is the git repository and revision a component was built from
+type GitSource struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision,omitempty"`
+	Context  string `json:"context,omitempty"`
+}
+
+// ParseSnapshotSpec decodes a raw Snapshot spec. Some snapshots carry the
+// application as "applicationName" rather than "application", so that is used
+// when "application" is missing or empty.
+func ParseSnapshotSpec(raw json.RawMessage) (*SnapshotSpec, error) {
+	var spec struct {
+		SnapshotSpec
+		ApplicationName string `json:"applicationName"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot spec: %w", err)
+	}
+	if spec.Application == "" {
+		spec.Application = spec.ApplicationName
+	}
+	return &spec.SnapshotSpec, nil
+}
+
+// DefaultComponentImageField is the component field holding the image in a
+// Konflux Snapshot, and the one the verify task reads
+const DefaultComponentImageField = "containerImage"
+
+// NormalizeComponentImageField returns the raw Snapshot spec with each
+// component's image copied from field to DefaultComponentImageField, for
+// snapshots using another schema such as "image" or "imageRef". Components
+// without the field are left as they are. The spec is returned unchanged
+// when field is empty or the default.
+func NormalizeComponentImageField(raw json.RawMessage, field string) (json.RawMessage, error) {
+	if field == "" || field == DefaultComponentImageField {
+		return raw, nil
+	}
+
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot spec: %w", err)
+	}
+	if _, ok := spec["components"]; !ok {
+		return raw, nil
+	}
+	var components []map[string]json.RawMessage
+	if err := json.Unmarshal(spec["components"], &components); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot components: %w", err)
+	}
+	for _, component := range components {
+		if image, ok := component[field]; ok {
+			component[DefaultComponentImageField] = image
+		}
+	}
+
+	var err error
+	if spec["components"], err = json.Marshal(components); err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot components: %w", err)
+	}
+	normalized, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot spec: %w", err)
+	}
+	return normalized, nil
+}
+
+// SnapshotTargetLabel is the Snapshot label naming the release target, the
+// namespace a matching ReleasePlan releases to
+const SnapshotTargetLabel = "release.appstudio.openshift.io/target"
+
+// Target returns the release target set on the snapshot, or "" if there isn't one
+func (r *Snapshot) Target() string {
+	return r.Labels[SnapshotTargetLabel]
+}
+
+// ApplicationName returns the application the snapshot belongs to
+func (r *Snapshot) ApplicationName() (string, error) {
+	spec, err := ParseSnapshotSpec(r.Spec)
+	if err != nil {
+		return "", err
+	}
+	return spec.Application, nil
+}
+
 // ---------------------------------------------------------------------------
 // ReleasePlan
 // ---------------------------------------------------------------------------
@@ -59,7 +164,8 @@ type ReleasePlan struct {
 
 type ReleasePlanSpec struct {
 	Application string `json:"application"`
-	Target      string `json:"target"`
+	// Target is the namespace the plan releases to, where its RPA lives
+	Target string `json:"target"`
 }
 
 type ReleasePlanList struct {
@@ -97,6 +203,21 @@ type ReleasePlanAdmission struct {
 
 type ReleasePlanAdmissionSpec struct {
 	Policy string `json:"policy"`
+	// PublicKeySecret optionally points at the public key used to verify
+	// images released through this RPA
+	PublicKeySecret *SecretKeyReference `json:"publicKeySecret,omitempty"`
+	// SigningKeySecret optionally names the Secret holding the key VSAs for
+	// images released through this RPA are signed with. It's mounted into
+	// the TaskRun, so it must be in the namespace the TaskRun is created in.
+	SigningKeySecret string `json:"signingKeySecret,omitempty"`
+}
+
+// SecretKeyReference identifies a single key in a Secret. An empty Namespace
+// means the namespace of the referencing object.
+type SecretKeyReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
 }
 
 func (r *ReleasePlanAdmission) DeepCopyObject() runtime.Object {
@@ -108,19 +229,65 @@ func (r *ReleasePlanAdmission) DeepCopyObject() runtime.Object {
 	return out
 }
 
+// ---------------------------------------------------------------------------
+// EnterpriseContractPolicy
+// ---------------------------------------------------------------------------
+// EnterpriseContractPolicy is only read to check it exists, so its spec is
+// left as it is
+type EnterpriseContractPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              json.RawMessage `json:"spec,omitempty"`
+}
+
+type EnterpriseContractPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnterpriseContractPolicy `json:"items"`
+}
+
+func (r *EnterpriseContractPolicy) DeepCopyObject() runtime.Object {
+	if r == nil {
+		return nil
+	}
+	out := new(EnterpriseContractPolicy)
+	*out = *r
+	return out
+}
+
+func (r *EnterpriseContractPolicyList) DeepCopyObject() runtime.Object {
+	if r == nil {
+		return nil
+	}
+	out := new(EnterpriseContractPolicyList)
+	*out = *r
+	return out
+}
+
 // ---------------------------------------------------------------------------
 // Use this to register the stub types defined here
 // ---------------------------------------------------------------------------
+// GroupVersion is the group and version of the Konflux types stubbed here
+var GroupVersion = schema.GroupVersion{
+	Group:   "appstudio.redhat.com",
+	Version: "v1alpha1",
+}
+
+// SnapshotGVK identifies the Snapshot resource
+var SnapshotGVK = GroupVersion.WithKind("Snapshot")
+
+// EnterpriseContractPolicyGVK identifies the EnterpriseContractPolicy resource
+var EnterpriseContractPolicyGVK = GroupVersion.WithKind("EnterpriseContractPolicy")
+
 func AddToScheme(s *runtime.Scheme) error {
-	gv := schema.GroupVersion{
-		Group:   "appstudio.redhat.com",
-		Version: "v1alpha1",
-	}
+	gv := GroupVersion
 	s.AddKnownTypes(gv,
 		&Snapshot{},
 		&ReleasePlan{},
 		&ReleasePlanList{},
 		&ReleasePlanAdmission{},
+		&EnterpriseContractPolicy{},
+		&EnterpriseContractPolicyList{},
 	)
 	metav1.AddToGroupVersion(s, gv)
 	return nil
@@ -17,12 +17,18 @@
 package konflux
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestSnapshot_DeepCopyObject(t *testing.T) {
@@ -107,6 +113,42 @@ func TestReleasePlanAdmission_DeepCopyObject(t *testing.T) {
 	assert.Nil(t, nilRPA.DeepCopyObject())
 }
 
+func TestEnterpriseContractPolicy_DeepCopyObject(t *testing.T) {
+	original := &EnterpriseContractPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ecp",
+			Namespace: "test-ns",
+		},
+		Spec: json.RawMessage(`{"sources":[{"policy":["oci::quay.io/policy"]}]}`),
+	}
+
+	copied := original.DeepCopyObject()
+
+	assert.NotSame(t, original, copied)
+	assert.Equal(t, original, copied)
+
+	// Verify nil handling
+	var nilECP *EnterpriseContractPolicy
+	assert.Nil(t, nilECP.DeepCopyObject())
+}
+
+func TestEnterpriseContractPolicyList_DeepCopyObject(t *testing.T) {
+	original := &EnterpriseContractPolicyList{
+		Items: []EnterpriseContractPolicy{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ecp1"}},
+		},
+	}
+
+	copied := original.DeepCopyObject()
+
+	assert.NotSame(t, original, copied)
+	assert.Equal(t, original, copied)
+
+	// Verify nil handling
+	var nilECPL *EnterpriseContractPolicyList
+	assert.Nil(t, nilECPL.DeepCopyObject())
+}
+
 func TestAddToScheme(t *testing.T) {
 	scheme := runtime.NewScheme()
 
@@ -119,3 +161,49 @@ func TestAddToScheme(t *testing.T) {
 	assert.Equal(t, "appstudio.redhat.com", gvks[0].Group)
 	assert.Equal(t, "v1alpha1", gvks[0].Version)
 }
+
+func TestSnapshotGVK(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, AddToScheme(scheme))
+
+	gvks, _, err := scheme.ObjectKinds(&Snapshot{})
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupVersionKind{SnapshotGVK}, gvks)
+}
+
+func TestEnterpriseContractPolicyGVK(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, AddToScheme(scheme))
+
+	gvks, _, err := scheme.ObjectKinds(&EnterpriseContractPolicy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupVersionKind{EnterpriseContractPolicyGVK}, gvks)
+
+	gvks, _, err = scheme.ObjectKinds(&EnterpriseContractPolicyList{})
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupVersionKind{GroupVersion.WithKind("EnterpriseContractPolicyList")}, gvks)
+}
+
+func TestEnterpriseContractPolicy_FakeClient(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, AddToScheme(scheme))
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&EnterpriseContractPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ecp", Namespace: "test-ns"},
+			Spec:       json.RawMessage(`{"description":"test"}`),
+		}).
+		Build()
+
+	ecp := &EnterpriseContractPolicy{}
+	require.NoError(t, cli.Get(context.Background(), client.ObjectKey{Namespace: "test-ns", Name: "test-ecp"}, ecp))
+	assert.Equal(t, "test-ecp", ecp.Name)
+	assert.JSONEq(t, `{"description":"test"}`, string(ecp.Spec))
+
+	err := cli.Get(context.Background(), client.ObjectKey{Namespace: "test-ns", Name: "missing"}, &EnterpriseContractPolicy{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	list := &EnterpriseContractPolicyList{}
+	require.NoError(t, cli.List(context.Background(), list, client.InNamespace("test-ns")))
+	assert.Len(t, list.Items, 1)
+}
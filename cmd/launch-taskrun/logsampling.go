@@ -0,0 +1,69 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"time"
+
+	gozap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// withLogSampling limits how many Debug and Info entries with the same
+// message are logged each second to the first initial, then every
+// thereafter-th one, so an event storm can't flood the logs. Warn and Error
+// entries are always logged. Sampling is disabled when initial isn't
+// positive.
+func withLogSampling(logger *gozap.Logger, initial, thereafter int) *gozap.Logger {
+	if initial <= 0 {
+		return logger
+	}
+	return logger.WithOptions(gozap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		sampled := belowLevelCore{
+			Core:  zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter),
+			level: zapcore.WarnLevel,
+		}
+		unsampled, err := zapcore.NewIncreaseLevelCore(core, zapcore.WarnLevel)
+		if err != nil {
+			// The core doesn't log anything below Warn anyway
+			return core
+		}
+		return zapcore.NewTee(sampled, unsampled)
+	}))
+}
+
+// belowLevelCore only logs the entries of the wrapped core that are below
+// level
+type belowLevelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c belowLevelCore) Enabled(level zapcore.Level) bool {
+	return level < c.level && c.Core.Enabled(level)
+}
+
+func (c belowLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return belowLevelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c belowLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level >= c.level {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
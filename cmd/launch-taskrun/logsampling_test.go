@@ -0,0 +1,76 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gozap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithLogSampling(t *testing.T) {
+	tests := []struct {
+		name       string
+		initial    int
+		thereafter int
+		infos      int
+	}{
+		{name: "disabled", initial: 0, thereafter: 0, infos: 100},
+		{name: "initial then every thereafter", initial: 10, thereafter: 10, infos: 19},
+		{name: "initial only", initial: 5, thereafter: 0, infos: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			logger := &zapLogger{l: withLogSampling(gozap.New(core), tt.initial, tt.thereafter)}
+
+			// A burst well within the sampler's one second tick
+			for range 100 {
+				logger.Info("Processing Snapshot")
+				logger.Debug("SpecJSON")
+				logger.Warn("Operation failed, retrying")
+				logger.Error(errors.New("boom"), "Failed to read configmap")
+			}
+
+			assert.Equal(t, tt.infos, logs.FilterMessage("Processing Snapshot").Len())
+			assert.Equal(t, tt.infos, logs.FilterMessage("SpecJSON").Len())
+			assert.Equal(t, 100, logs.FilterMessage("Operation failed, retrying").Len(), "Warn is never sampled")
+			assert.Equal(t, 100, logs.FilterMessage("Failed to read configmap").Len(), "Error is never sampled")
+		})
+	}
+}
+
+func TestWithLogSampling_With(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := withLogSampling(gozap.New(core), 1, 0).With(gozap.String("component", "test"))
+
+	for range 10 {
+		logger.Info("Processing Snapshot")
+		logger.Debug("Below the core's level")
+		logger.Warn("Operation failed, retrying")
+	}
+
+	assert.Equal(t, 1, logs.FilterMessage("Processing Snapshot").Len())
+	assert.Equal(t, 0, logs.FilterMessage("Below the core's level").Len())
+	assert.Equal(t, 10, logs.FilterMessage("Operation failed, retrying").Len())
+	assert.Equal(t, 11, logs.FilterField(gozap.String("component", "test")).Len())
+}
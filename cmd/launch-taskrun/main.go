@@ -17,32 +17,57 @@
 package main
 
 import (
+	"bytes"
+	"container/list"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
+	"unicode"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	ceclient "github.com/cloudevents/sdk-go/v2/client"
 	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/distribution/reference"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	tektonclientset "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	tektontypedv1 "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/typed/pipeline/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
+	authorizationtypedv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	coretypedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"go.opentelemetry.io/otel/propagation"
 	gozap "go.uber.org/zap"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/conforma/knative-service/cmd/launch-taskrun/k8s"
 	"github.com/conforma/knative-service/cmd/launch-taskrun/konflux"
+	"github.com/conforma/knative-service/cmd/launch-taskrun/retry"
 )
 
 // --- Interfaces for testability ---
@@ -50,16 +75,34 @@ type K8sConfigMapGetter interface {
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error)
 }
 
+type K8sNamespaceGetter interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Namespace, error)
+}
+
 type K8sCoreV1 interface {
 	ConfigMaps(namespace string) K8sConfigMapGetter
+	Namespaces() K8sNamespaceGetter
+}
+
+type K8sSelfSubjectAccessReviewer interface {
+	Create(ctx context.Context, review *authorizationv1.SelfSubjectAccessReview, opts metav1.CreateOptions) (*authorizationv1.SelfSubjectAccessReview, error)
+}
+
+type K8sAuthorizationV1 interface {
+	SelfSubjectAccessReviews() K8sSelfSubjectAccessReviewer
 }
 
 type K8sClient interface {
 	CoreV1() K8sCoreV1
+	AuthorizationV1() K8sAuthorizationV1
 }
 
 type TektonTaskRunCreator interface {
 	Create(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.CreateOptions) (*tektonv1.TaskRun, error)
+	// Apply creates or updates the TaskRun with server-side apply
+	Apply(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.ApplyOptions) (*tektonv1.TaskRun, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*tektonv1.TaskRunList, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 }
 
 type TektonV1 interface {
@@ -77,6 +120,7 @@ type ControllerRuntimeClient interface {
 
 // --- Logger interface and zapLogger ---
 type Logger interface {
+	Debug(msg string, fields ...gozap.Field)
 	Info(msg string, fields ...gozap.Field)
 	Warn(msg string, fields ...gozap.Field)
 	Error(err error, msg string, fields ...gozap.Field)
@@ -86,41 +130,91 @@ type zapLogger struct {
 	l *gozap.Logger
 }
 
-func (z *zapLogger) Info(msg string, fields ...gozap.Field) { z.l.Info(msg, fields...) }
-func (z *zapLogger) Warn(msg string, fields ...gozap.Field) { z.l.Warn(msg, fields...) }
+func (z *zapLogger) Debug(msg string, fields ...gozap.Field) { z.l.Debug(msg, fields...) }
+func (z *zapLogger) Info(msg string, fields ...gozap.Field)  { z.l.Info(msg, fields...) }
+func (z *zapLogger) Warn(msg string, fields ...gozap.Field)  { z.l.Warn(msg, fields...) }
 func (z *zapLogger) Error(err error, msg string, fields ...gozap.Field) {
 	z.l.Error(msg, append(fields, gozap.Error(err))...)
 }
 
 // --- ConfigMap Cache ---
+
+// defaultCacheMaxEntries bounds the number of namespaces with cached config
+// when CACHE_MAX_ENTRIES isn't set
+const defaultCacheMaxEntries = 1000
+
+// configMapCache caches config per namespace. Entries expire after the TTL and
+// the least recently used entry is evicted once maxEntries is reached.
 type configMapCache struct {
-	mu    sync.RWMutex
-	cache map[string]*cachedConfigMap
-	ttl   time.Duration
+	mu         sync.Mutex
+	cache      map[string]*cachedConfigMap
+	order      *list.List // keys, most recently used at the front
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+	hits       uint64
+	misses     uint64
+}
+
+// configMapCacheStats is a point in time view of the cache's effectiveness
+type configMapCacheStats struct {
+	entries int
+	hits    uint64
+	misses  uint64
+}
+
+// hitRatio is the fraction of lookups served from the cache, zero when there
+// haven't been any
+func (st configMapCacheStats) hitRatio() float64 {
+	if st.hits+st.misses == 0 {
+		return 0
+	}
+	return float64(st.hits) / float64(st.hits+st.misses)
 }
 
 type cachedConfigMap struct {
 	config    *TaskRunConfig
 	timestamp time.Time
+	element   *list.Element
 }
 
-func newConfigMapCache(ttl time.Duration) *configMapCache {
+func newConfigMapCache(ttl time.Duration, maxEntries int) *configMapCache {
 	return &configMapCache{
-		cache: make(map[string]*cachedConfigMap),
-		ttl:   ttl,
+		cache:      make(map[string]*cachedConfigMap),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
 	}
 }
 
 func (c *configMapCache) get(key string) (*TaskRunConfig, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	if cached, exists := c.cache[key]; exists {
-		if time.Since(cached.timestamp) < c.ttl {
+		if c.now().Sub(cached.timestamp) < c.ttl {
+			c.order.MoveToFront(cached.element)
+			c.hits++
+			configMapCacheHits.Inc()
 			return cached.config, true
 		}
 		// Cache expired, remove it
-		delete(c.cache, key)
+		c.remove(key)
+	}
+	c.misses++
+	configMapCacheMisses.Inc()
+	return nil, false
+}
+
+// peek returns an unexpired entry like get, but leaves the entry's recency
+// and the hit and miss counts alone
+func (c *configMapCache) peek(key string) (*TaskRunConfig, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, exists := c.cache[key]; exists && c.now().Sub(cached.timestamp) < c.ttl {
+		return cached.config, true
 	}
 	return nil, false
 }
@@ -129,12 +223,71 @@ func (c *configMapCache) set(key string, config *TaskRunConfig) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if cached, exists := c.cache[key]; exists {
+		cached.config = config
+		cached.timestamp = c.now()
+		c.order.MoveToFront(cached.element)
+		return
+	}
+
 	c.cache[key] = &cachedConfigMap{
 		config:    config,
-		timestamp: time.Now(),
+		timestamp: c.now(),
+		element:   c.order.PushFront(key),
+	}
+
+	for c.maxEntries > 0 && len(c.cache) > c.maxEntries {
+		c.remove(c.order.Back().Value.(string))
+	}
+	configMapCacheEntries.Set(float64(len(c.cache)))
+}
+
+// stats returns the current size and lookup counts
+func (c *configMapCache) stats() configMapCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return configMapCacheStats{entries: len(c.cache), hits: c.hits, misses: c.misses}
+}
+
+// sweep removes all expired entries, returning how many were removed
+func (c *configMapCache) sweep() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	now := c.now()
+	for key, cached := range c.cache {
+		if now.Sub(cached.timestamp) >= c.ttl {
+			c.remove(key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// remove deletes an entry, the caller must hold the lock
+func (c *configMapCache) remove(key string) {
+	if cached, exists := c.cache[key]; exists {
+		c.order.Remove(cached.element)
+		delete(c.cache, key)
+		configMapCacheEntries.Set(float64(len(c.cache)))
 	}
 }
 
+// setTTL changes how long entries are kept, applying to existing entries too
+func (c *configMapCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// getTTL returns how long entries are currently kept
+func (c *configMapCache) getTTL() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl
+}
+
 // clear removes all entries from the cache
 // This method is currently unused but kept for potential future use
 //
@@ -143,6 +296,65 @@ func (c *configMapCache) clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.cache = make(map[string]*cachedConfigMap)
+	c.order.Init()
+	configMapCacheEntries.Set(0)
+}
+
+// defaultSecretCacheTTL is how long secret values are cached when
+// SECRET_CACHE_TTL_SECONDS isn't set
+const defaultSecretCacheTTL = time.Minute
+
+// secretValueCache caches values read from secrets, keyed by namespace, name
+// and key, so repeated lookups of the same secret don't each hit the API.
+// Entries expire after the TTL.
+type secretValueCache struct {
+	mu    sync.Mutex
+	cache map[string]cachedSecretValue
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+type cachedSecretValue struct {
+	value     string
+	timestamp time.Time
+}
+
+func newSecretValueCache(ttl time.Duration) *secretValueCache {
+	return &secretValueCache{
+		cache: make(map[string]cachedSecretValue),
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+func secretValueCacheKey(namespace, name, key string) string {
+	return namespace + "/" + name + "/" + key
+}
+
+func (c *secretValueCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, exists := c.cache[key]; exists {
+		if c.now().Sub(cached.timestamp) < c.ttl {
+			return cached.value, true
+		}
+		// Cache expired, remove it
+		delete(c.cache, key)
+	}
+	return "", false
+}
+
+func (c *secretValueCache) set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache[key] = cachedSecretValue{value: value, timestamp: c.now()}
+}
+
+func (c *secretValueCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cache, key)
 }
 
 // --- Real implementations ---
@@ -150,12 +362,28 @@ type realK8sClient struct{ client *kubernetes.Clientset }
 
 func (r *realK8sClient) CoreV1() K8sCoreV1 { return &realK8sCoreV1{client: r.client.CoreV1()} }
 
+func (r *realK8sClient) AuthorizationV1() K8sAuthorizationV1 {
+	return &realK8sAuthorizationV1{client: r.client.AuthorizationV1()}
+}
+
+type realK8sAuthorizationV1 struct {
+	client authorizationtypedv1.AuthorizationV1Interface
+}
+
+func (r *realK8sAuthorizationV1) SelfSubjectAccessReviews() K8sSelfSubjectAccessReviewer {
+	return r.client.SelfSubjectAccessReviews()
+}
+
 type realK8sCoreV1 struct{ client coretypedv1.CoreV1Interface }
 
 func (r *realK8sCoreV1) ConfigMaps(ns string) K8sConfigMapGetter {
 	return &realK8sConfigMapGetter{client: r.client.ConfigMaps(ns)}
 }
 
+func (r *realK8sCoreV1) Namespaces() K8sNamespaceGetter {
+	return r.client.Namespaces()
+}
+
 type realK8sConfigMapGetter struct {
 	client coretypedv1.ConfigMapInterface
 }
@@ -164,9 +392,18 @@ func (r *realK8sConfigMapGetter) Get(ctx context.Context, name string, opts meta
 	return r.client.Get(ctx, name, opts)
 }
 
-type realTektonClient struct{ client *tektonclientset.Clientset }
+type realTektonClient struct {
+	client *tektonclientset.Clientset
+	// The API version TaskRuns are stored with, v1 unless set to v1beta1
+	apiVersion TektonAPIVersion
+}
 
-func (r *realTektonClient) TektonV1() TektonV1 { return &realTektonV1{client: r.client.TektonV1()} }
+func (r *realTektonClient) TektonV1() TektonV1 {
+	if r.apiVersion == TektonAPIVersionV1beta1 {
+		return &realTektonV1beta1{client: r.client.TektonV1beta1()}
+	}
+	return &realTektonV1{client: r.client.TektonV1()}
+}
 
 type realTektonV1 struct {
 	client tektontypedv1.TektonV1Interface
@@ -184,6 +421,25 @@ func (r *realTektonTaskRunCreator) Create(ctx context.Context, taskRun *tektonv1
 	return r.client.Create(ctx, taskRun, opts)
 }
 
+func (r *realTektonTaskRunCreator) Apply(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.ApplyOptions) (*tektonv1.TaskRun, error) {
+	taskRun = taskRun.DeepCopy()
+	taskRun.APIVersion = tektonv1.SchemeGroupVersion.String()
+	taskRun.Kind = "TaskRun"
+	data, err := json.Marshal(taskRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode TaskRun %s: %w", taskRun.Name, err)
+	}
+	return r.client.Patch(ctx, taskRun.Name, types.ApplyPatchType, data, opts.ToPatchOptions())
+}
+
+func (r *realTektonTaskRunCreator) List(ctx context.Context, opts metav1.ListOptions) (*tektonv1.TaskRunList, error) {
+	return r.client.List(ctx, opts)
+}
+
+func (r *realTektonTaskRunCreator) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return r.client.Delete(ctx, name, opts)
+}
+
 // --- CloudEvents client abstraction ---
 type CloudEventsClient interface {
 	StartReceiver(ctx context.Context, fn interface{}) error
@@ -197,6 +453,41 @@ func (r *realCloudEventsClient) StartReceiver(ctx context.Context, fn interface{
 	return r.client.StartReceiver(ctx, fn)
 }
 
+// EventSender delivers outbound CloudEvents
+type EventSender interface {
+	Send(ctx context.Context, event cloudevents.Event) error
+}
+
+type realEventSender struct {
+	client cloudevents.Client
+	target string
+}
+
+// NewEventSender returns an EventSender that delivers events to sinkURL with
+// httpClient
+func NewEventSender(sinkURL string, httpClient *http.Client) (EventSender, error) {
+	if _, err := url.ParseRequestURI(sinkURL); err != nil {
+		return nil, fmt.Errorf("invalid sink URL %q: %w", sinkURL, err)
+	}
+	protocol, err := cehttp.New(cehttp.WithClient(*httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create protocol: %w", err)
+	}
+	client, err := ceclient.New(protocol, ceclient.WithUUIDs(), ceclient.WithTimeNow())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CloudEvents client: %w", err)
+	}
+	return &realEventSender{client: client, target: sinkURL}, nil
+}
+
+func (r *realEventSender) Send(ctx context.Context, event cloudevents.Event) error {
+	result := r.client.Send(cloudevents.ContextWithTarget(ctx, r.target), event)
+	if !cloudevents.IsACK(result) {
+		return result
+	}
+	return nil
+}
+
 type realControllerRuntimeClient struct {
 	client client.Client
 }
@@ -215,10 +506,98 @@ type CloudEventData struct {
 	APIVersion string `json:"apiVersion"`
 	Kind       string `json:"kind"`
 	Metadata   struct {
-		Name      string `json:"name"`
-		Namespace string `json:"namespace"`
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
 	} `json:"metadata"`
 	Spec json.RawMessage `json:"spec"`
+
+	// An ApiServerSource in Reference mode sends only the object's identity,
+	// with the name and namespace at the top level rather than in metadata
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// objectName returns the name and namespace of the event's object, whichever
+// payload format it uses
+func (d *CloudEventData) objectName() (string, string) {
+	if d.Metadata.Name != "" {
+		return d.Metadata.Name, d.Metadata.Namespace
+	}
+	return d.Name, d.Namespace
+}
+
+// isReference reports whether the event only identifies the object, so it
+// has to be fetched to be processed
+func (d *CloudEventData) isReference() bool {
+	return len(bytes.TrimSpace(d.Spec)) == 0 || bytes.Equal(bytes.TrimSpace(d.Spec), []byte("null"))
+}
+
+// EventMode is how the event payload is interpreted, matching the
+// ApiServerSource modes
+type EventMode string
+
+const (
+	// EventModeAuto fetches the snapshot when the event doesn't include its spec
+	EventModeAuto EventMode = "auto"
+	// EventModeResource expects the full snapshot in every event
+	EventModeResource EventMode = "resource"
+	// EventModeReference always fetches the snapshot the event refers to
+	EventModeReference EventMode = "reference"
+)
+
+// parseEventMode reads EVENT_MODE, defaulting to auto
+func parseEventMode(val string) (EventMode, error) {
+	switch mode := EventMode(strings.ToLower(strings.TrimSpace(val))); mode {
+	case "":
+		return EventModeAuto, nil
+	case EventModeAuto, EventModeResource, EventModeReference:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("EVENT_MODE %q is not supported, must be auto, resource or reference", val)
+	}
+}
+
+// parseEventResourcePath reads EVENT_RESOURCE_PATH, the dot separated field
+// names leading to the resource within the event data, e.g. "object" for
+// events wrapping it as {"object": {...}}. An optional leading "$" is
+// accepted, and an empty path, or "$" on its own, is the event data root.
+func parseEventResourcePath(val string) ([]string, error) {
+	path := strings.TrimPrefix(strings.TrimSpace(val), "$")
+	if path == "" {
+		return nil, nil
+	}
+	fields := strings.Split(strings.TrimPrefix(path, "."), ".")
+	for _, field := range fields {
+		if field == "" {
+			return nil, fmt.Errorf("EVENT_RESOURCE_PATH %q has an empty field name", val)
+		}
+	}
+	return fields, nil
+}
+
+// decodeEventData unmarshals the resource the event carries, found at the
+// event resource path within the event data
+func (s *Service) decodeEventData(event cloudevents.Event, eventData *CloudEventData) error {
+	if len(s.eventResourcePath) == 0 {
+		return event.DataAs(eventData)
+	}
+	var data json.RawMessage
+	if err := event.DataAs(&data); err != nil {
+		return err
+	}
+	for i, field := range s.eventResourcePath {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return fmt.Errorf("event data at %s is not an object: %w", strings.Join(s.eventResourcePath[:i], "."), err)
+		}
+		var ok bool
+		if data, ok = object[field]; !ok {
+			return fmt.Errorf("event data has no %s", strings.Join(s.eventResourcePath[:i+1], "."))
+		}
+	}
+	return json.Unmarshal(data, eventData)
 }
 
 type TaskRunConfig struct {
@@ -228,14 +607,31 @@ type TaskRunConfig struct {
 	IgnoreRekor             string `json:"IGNORE_REKOR"`
 	VsaSigningKeySecretName string `json:"VSA_SIGNING_KEY_SECRET_NAME"`
 	VsaUploadUrl            string `json:"VSA_UPLOAD_URL"`
+	VsaUploadUrlSecretName  string `json:"VSA_UPLOAD_URL_SECRET_NAME"`
+	VsaUploadUrlSecretKey   string `json:"VSA_UPLOAD_URL_SECRET_KEY"`
+	AllowedUploadSchemes    string `json:"ALLOWED_UPLOAD_SCHEMES"`
+	AllowedImageRegistries  string `json:"ALLOWED_IMAGE_REGISTRIES"`
 	TaskName                string `json:"TASK_NAME"`
+	TaskNamespace           string `json:"TASK_NAMESPACE"`
+	TaskKind                string `json:"TASK_KIND"`
+
+	// The component field holding the image, for snapshots that don't use
+	// containerImage
+	ComponentImageField string `json:"COMPONENT_IMAGE_FIELD"`
 
 	// Performance & Behavior Configuration
 	Strict  string `json:"STRICT"`
 	Workers string `json:"WORKERS"`
 	Debug   string `json:"DEBUG"`
+	// The workers used when WORKERS isn't set, and the most a TaskRun may
+	// ask for, see taskRunWorkers
+	DefaultWorkers string `json:"DEFAULT_WORKERS"`
+	MaxWorkers     string `json:"MAX_WORKERS"`
 
 	// Operational Configuration
+	// CacheTTLMinutes replaces the config cache TTL once read. The TTL is
+	// checked when an entry is looked up, so it applies to every cached
+	// entry, including the one holding the config it was read from.
 	CacheTTLMinutes      string `json:"CACHE_TTL_MINUTES"`
 	TektonTimeoutSeconds string `json:"TEKTON_TIMEOUT_SECONDS"`
 	VsaExpirationHours   string `json:"VSA_EXPIRATION_HOURS"`
@@ -247,11 +643,97 @@ type TaskRunConfig struct {
 	K8sRetryDelaySeconds    string `json:"K8S_RETRY_DELAY_SECONDS"`
 	CircuitBreakerThreshold string `json:"CIRCUIT_BREAKER_THRESHOLD"`
 	CircuitBreakerTimeout   string `json:"CIRCUIT_BREAKER_TIMEOUT_SECONDS"`
+	CircuitBreakerFailMode  string `json:"CIRCUIT_BREAKER_FAIL_MODE"`
+	// Once the timeout has passed, operations are let through as probes at
+	// most this often, and the breaker closes after CIRCUIT_BREAKER_PROBES
+	// of them succeed in a row
+	CircuitBreakerProbeInterval string `json:"CIRCUIT_BREAKER_PROBE_INTERVAL_SECONDS"`
+	CircuitBreakerProbes        string `json:"CIRCUIT_BREAKER_PROBES"`
 
 	// Resource Configuration
 	TaskCpuRequest    string `json:"TASK_CPU_REQUEST"`
 	TaskMemoryRequest string `json:"TASK_MEMORY_REQUEST"`
 	TaskMemoryLimit   string `json:"TASK_MEMORY_LIMIT"`
+
+	// Environment Configuration
+	TaskRunEnv string `json:"TASKRUN_ENV"`
+
+	// JSON object of additional TaskRun params, see parseTaskRunExtraParams
+	TaskRunExtraParams string `json:"TASKRUN_EXTRA_PARAMS"`
+
+	// PriorityClass for the TaskRun's pod
+	TaskRunPriorityClass string `json:"TASKRUN_PRIORITY_CLASS"`
+
+	// Prefix of the TaskRun names, see taskRunNamePrefix
+	TaskRunNamePrefix string `json:"TASKRUN_NAME_PREFIX"`
+
+	// Run the TaskRun's pod with the restricted security context, see
+	// restrictedPodSecurityContext
+	ApplyRestrictedSecurityContext string `json:"APPLY_RESTRICTED_SECURITY_CONTEXT"`
+
+	// Workspace Configuration
+	ScratchWorkspaceName      string `json:"SCRATCH_WORKSPACE_NAME"`
+	ScratchWorkspaceType      string `json:"SCRATCH_WORKSPACE_TYPE"`
+	ScratchWorkspaceClaimName string `json:"SCRATCH_WORKSPACE_CLAIM_NAME"`
+
+	// Logging Configuration
+	LogStreamingAnnotationKey   string `json:"LOG_STREAMING_ANNOTATION_KEY"`
+	LogStreamingAnnotationValue string `json:"LOG_STREAMING_ANNOTATION_VALUE"`
+
+	// Comma separated prefixes of Snapshot annotations to copy to the TaskRun
+	PropagateAnnotationPrefixes string `json:"PROPAGATE_ANNOTATION_PREFIXES"`
+	// Snapshot label naming the PipelineRun that built it, copied to the
+	// TaskRun so the two can be correlated
+	PipelineRunLabelKey string `json:"PIPELINERUN_LABEL_KEY"`
+
+	// Policy Configuration
+	ApplicationPolicyOverrides string `json:"APPLICATION_POLICY_OVERRIDES"`
+	// Comma separated policy sources, tried in turn, see resolvePolicy
+	PolicyResolutionOrder string `json:"POLICY_RESOLUTION_ORDER"`
+
+	// "log" to only log the TaskRun a snapshot would get, or "mark" to
+	// create it with dryRunLabel, see dryRunMode
+	DryRunMode string `json:"DRY_RUN_MODE"`
+
+	// What the application and releaseplan policy sources do with a snapshot
+	// without an application, "skip" or "error"
+	MissingApplicationMode string `json:"MISSING_APPLICATION_MODE"`
+
+	// Missing ReleasePlan Configuration
+	RetryOnMissingReleasePlan      string `json:"RETRY_ON_MISSING_RELEASEPLAN"`
+	MissingReleasePlanGraceSeconds string `json:"MISSING_RELEASEPLAN_GRACE_SECONDS"`
+
+	// Check the snapshot's namespace still exists before creating a TaskRun
+	VerifyNamespaceExists string `json:"VERIFY_NAMESPACE_EXISTS"`
+
+	// Check the resolved EnterpriseContractPolicy exists before creating a
+	// TaskRun that refers to it
+	VerifyPolicyExists string `json:"VERIFY_POLICY_EXISTS"`
+
+	// What to do when several ReleasePlans match, "first" or "error"
+	ReleasePlanAmbiguityMode string `json:"RELEASEPLAN_AMBIGUITY_MODE"`
+
+	// Comma separated namespaces searched in order for a ReleasePlan when
+	// the snapshot's namespace has none
+	ReleasePlanSearchNamespaces string `json:"RELEASEPLAN_SEARCH_NAMESPACES"`
+
+	// Only match ReleasePlans whose target is the snapshot's target label
+	MatchReleasePlanByTarget string `json:"MATCH_RELEASEPLAN_BY_TARGET"`
+
+	// Skip snapshots already verified by a Succeeded TaskRun
+	ReuseSucceededTaskRuns string `json:"REUSE_SUCCEEDED_TASKRUNS"`
+
+	// Create TaskRuns with server-side apply, named after the snapshot alone
+	// so a redelivered snapshot updates its TaskRun
+	ApplyTaskRuns string `json:"APPLY_TASKRUNS"`
+
+	// Copy the snapshot's displayName and displayDescription to TaskRun
+	// annotations, so UIs can show them next to the TaskRun
+	AnnotateSnapshotDisplayInfo string `json:"ANNOTATE_SNAPSHOT_DISPLAY_INFO"`
+
+	// Create TaskRuns for snapshots without components, which are otherwise
+	// skipped as there's nothing to verify
+	ProcessEmptySnapshots string `json:"PROCESS_EMPTY_SNAPSHOTS"`
 }
 
 // CircuitBreakerState tracks the state of external service calls
@@ -260,21 +742,151 @@ type CircuitBreakerState struct {
 	failures    int
 	lastFailure time.Time
 	isOpen      bool
+	// An open breaker goes half-open once its timeout has passed, letting
+	// probes through to decide whether to close or reopen
+	halfOpen         bool
+	successfulProbes int
+	lastProbe        time.Time
 }
 
 type Service struct {
-	k8sClient      K8sClient
-	tektonClient   TektonClient
-	crtlClient     ControllerRuntimeClient
-	logger         Logger
-	configMapName  string
-	configCache    *configMapCache
-	circuitBreaker *CircuitBreakerState
+	k8sClient     K8sClient
+	tektonClient  TektonClient
+	crtlClient    ControllerRuntimeClient
+	logger        Logger
+	configMapName string
+	// The config maps merged into the config, see ServiceConfig.ConfigMapNames
+	configMapNames          []string
+	ignoreMissingConfigMaps bool
+	configCache             *configMapCache
+	secretCache             *secretValueCache
+	acceptedGVK             schema.GroupVersionKind
+	eventMode               EventMode
+	// Fields leading to the resource within the event data, empty for the root
+	eventResourcePath []string
+	// Upper bound on handling a single event, zero means no limit
+	requestTimeout time.Duration
+	// Upper bound on processing a single snapshot, zero means no limit
+	processTimeout time.Duration
+	// Look up the policy while the config is read, see readConfigAndPrefetchEcp
+	concurrentPolicyLookup bool
+	// Decides which failed operations are retried
+	retryClassifier RetryClassifier
+	// Names the TaskRun created for a snapshot
+	taskRunName TaskRunNameGenerator
+	// Failed deliveries per event, nil when MAX_REDELIVERIES isn't set
+	deliveries       *deliveryTracker
+	maxRedeliveries  int
+	deadLetterSender EventSender
+	// Whether /debug/config and /debug/stats are served
+	debugEndpoints bool
+	eventSender    EventSender
+	// Write-ahead log of events being processed, nil when disabled
+	eventLog *eventLog
+	// Audit trail of processed snapshots, nil when disabled
+	auditLog *auditLog
+	// Snapshot outcomes per namespace, served by /debug/stats
+	namespaceStats *namespaceStats
+
+	// One circuit breaker per operation, created on first use
+	breakersMu      sync.Mutex
+	circuitBreakers map[string]*CircuitBreakerState
+
+	// statusMu guards the processing status reported by /readyz
+	statusMu    sync.RWMutex
+	lastSuccess time.Time
+	// Set once a config map was read successfully, until then /readyz
+	// reports the service isn't ready
+	configReadyOnce bool
+
+	// Snapshots waiting for a ReleasePlan, keyed by namespace/name, with the
+	// time their ReleasePlan was first found missing
+	missingReleasePlanMu sync.Mutex
+	missingReleasePlans  map[string]time.Time
+
+	// Background work is tied to this context and stopped by Close
+	backgroundCtx    context.Context
+	backgroundCancel context.CancelFunc
+	backgroundWg     sync.WaitGroup
+	closeOnce        sync.Once
 }
 
 type ServiceConfig struct {
 	ConfigMapName string
-	CacheTTL      time.Duration
+	// ConfigMapNames, when set, replaces ConfigMapName with several config
+	// maps merged in order, later ones overriding the keys of earlier ones
+	ConfigMapNames []string
+	// Whether a config map missing from ConfigMapNames is skipped rather
+	// than failing the read. At least one of them must exist.
+	IgnoreMissingConfigMaps bool
+	CacheTTL                time.Duration
+	CacheMaxEntries         int
+	// How often expired cache entries are removed, zero disables the sweeper
+	CacheSweepInterval time.Duration
+	// How often cache stats are logged at debug level, zero disables them
+	CacheStatsInterval time.Duration
+	// How long values read from secrets are cached
+	SecretCacheTTL time.Duration
+
+	// The apiVersion and kind of the resources to process, defaulting to
+	// the Konflux Snapshot
+	SnapshotAPIVersion string
+	SnapshotKind       string
+
+	// How the event payload is interpreted, defaulting to EventModeAuto
+	EventMode EventMode
+
+	// The fields leading to the resource within the event data, see
+	// parseEventResourcePath. Empty means the event data is the resource.
+	EventResourcePath []string
+
+	// The tekton.dev API version used for TaskRuns, defaulting to
+	// TektonAPIVersionAuto
+	TektonAPIVersion TektonAPIVersion
+
+	// How long a single event may be processed before it's cancelled, zero
+	// disables the timeout
+	RequestTimeout time.Duration
+
+	// How long processing a single snapshot may take, from reading the config
+	// to creating the TaskRun, zero disables the timeout
+	ProcessTimeout time.Duration
+
+	// EventSender is notified when a TaskRun is created, nil disables the
+	// outbound events
+	EventSender EventSender
+
+	// Serve the /debug endpoints that expose internal state, such as
+	// /debug/config and /debug/stats
+	DebugEndpointsEnabled bool
+
+	// Look up the ReleasePlan policy while the config map is read, rather
+	// than after, to cut the time taken to process a snapshot
+	ConcurrentPolicyLookup bool
+
+	// RetryClassifier decides which failed operations are retried,
+	// defaulting to isRetryableError
+	RetryClassifier RetryClassifier
+
+	// TaskRunNameGenerator names the TaskRun created for a snapshot,
+	// defaulting to defaultTaskRunName. It isn't used with APPLY_TASKRUNS,
+	// which needs the same name every time.
+	TaskRunNameGenerator TaskRunNameGenerator
+
+	// How many times an event may be redelivered after failing before it's
+	// given up on, zero redelivers it for as long as the sender keeps trying
+	MaxRedeliveries int
+
+	// DeadLetterSender is sent the events given up on after MaxRedeliveries,
+	// nil only logs them
+	DeadLetterSender EventSender
+
+	// Debug and Info entries with the same message are logged for the first
+	// LogSamplingInitial times each second, then every LogSamplingThereafter
+	// times, or not at all if it's zero. Zero LogSamplingInitial disables
+	// sampling. Only used by NewService.
+	LogSamplingInitial    int
+	LogSamplingThereafter int
 }
 
 func NewServiceWithDependencies(k8s K8sClient, tekton TektonClient, crtlClient ControllerRuntimeClient, logger Logger, config ServiceConfig) *Service {
@@ -284,15 +896,128 @@ func NewServiceWithDependencies(k8s K8sClient, tekton TektonClient, crtlClient C
 	if config.CacheTTL == 0 {
 		config.CacheTTL = 5 * time.Minute // Default 5 minute TTL
 	}
-	return &Service{
-		k8sClient:      k8s,
-		tektonClient:   tekton,
-		crtlClient:     crtlClient,
-		logger:         logger,
-		configMapName:  config.ConfigMapName,
-		configCache:    newConfigMapCache(config.CacheTTL),
-		circuitBreaker: &CircuitBreakerState{},
-	}
+	if config.CacheMaxEntries == 0 {
+		config.CacheMaxEntries = defaultCacheMaxEntries
+	}
+	if config.SecretCacheTTL == 0 {
+		config.SecretCacheTTL = defaultSecretCacheTTL
+	}
+	if config.SnapshotAPIVersion == "" {
+		config.SnapshotAPIVersion = konflux.SnapshotGVK.GroupVersion().String()
+	}
+	if config.SnapshotKind == "" {
+		config.SnapshotKind = konflux.SnapshotGVK.Kind
+	}
+	if config.EventMode == "" {
+		config.EventMode = EventModeAuto
+	}
+	if config.RetryClassifier == nil {
+		config.RetryClassifier = isRetryableError
+	}
+	if config.TaskRunNameGenerator == nil {
+		config.TaskRunNameGenerator = defaultTaskRunName
+	}
+	backgroundCtx, backgroundCancel := context.WithCancel(context.Background())
+	s := &Service{
+		k8sClient:               k8s,
+		tektonClient:            tekton,
+		crtlClient:              crtlClient,
+		logger:                  logger,
+		configMapName:           config.ConfigMapName,
+		configMapNames:          config.ConfigMapNames,
+		ignoreMissingConfigMaps: config.IgnoreMissingConfigMaps,
+		configCache:             newConfigMapCache(config.CacheTTL, config.CacheMaxEntries),
+		secretCache:             newSecretValueCache(config.SecretCacheTTL),
+		acceptedGVK:             schema.FromAPIVersionAndKind(config.SnapshotAPIVersion, config.SnapshotKind),
+		eventMode:               config.EventMode,
+		eventResourcePath:       config.EventResourcePath,
+		requestTimeout:          config.RequestTimeout,
+		processTimeout:          config.ProcessTimeout,
+		concurrentPolicyLookup:  config.ConcurrentPolicyLookup,
+		retryClassifier:         config.RetryClassifier,
+		taskRunName:             config.TaskRunNameGenerator,
+		maxRedeliveries:         config.MaxRedeliveries,
+		deadLetterSender:        config.DeadLetterSender,
+		debugEndpoints:          config.DebugEndpointsEnabled,
+		eventSender:             config.EventSender,
+		circuitBreakers:         make(map[string]*CircuitBreakerState),
+		missingReleasePlans:     make(map[string]time.Time),
+		namespaceStats:          newNamespaceStats(maxNamespaceStats),
+		backgroundCtx:           backgroundCtx,
+		backgroundCancel:        backgroundCancel,
+	}
+	if config.MaxRedeliveries > 0 {
+		s.deliveries = newDeliveryTracker(deliveryTrackerTTL, deliveryTrackerMaxEntries)
+	}
+	if config.CacheSweepInterval > 0 {
+		s.startCacheSweeper(config.CacheSweepInterval)
+	}
+	if config.CacheStatsInterval > 0 {
+		s.startCacheStatsLogger(config.CacheStatsInterval)
+	}
+	return s
+}
+
+// startCacheSweeper periodically removes expired config cache entries so
+// namespaces that stop sending events don't stay cached. It runs until the
+// service is closed.
+func (s *Service) startCacheSweeper(interval time.Duration) {
+	s.runInBackground(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if removed := s.configCache.sweep(); removed > 0 {
+					s.logger.Info("Removed expired config cache entries", gozap.Int("count", removed))
+				}
+			}
+		}
+	})
+}
+
+// startCacheStatsLogger periodically logs the cache size and hit ratio to
+// help with tuning the cache TTL
+func (s *Service) startCacheStatsLogger(interval time.Duration) {
+	s.runInBackground(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stats := s.configCache.stats()
+				s.logger.Debug("Config cache stats",
+					gozap.Int("entries", stats.entries),
+					gozap.Uint64("hits", stats.hits),
+					gozap.Uint64("misses", stats.misses),
+					gozap.Float64("hitRatio", stats.hitRatio()))
+			}
+		}
+	})
+}
+
+// runInBackground starts fn in a goroutine that is tied to the service
+// lifecycle. The context passed to fn is cancelled when the service is closed.
+func (s *Service) runInBackground(fn func(ctx context.Context)) {
+	s.backgroundWg.Add(1)
+	go func() {
+		defer s.backgroundWg.Done()
+		fn(s.backgroundCtx)
+	}()
+}
+
+// Close stops any background work started by the service and waits for it to
+// finish. It's safe to call more than once.
+func (s *Service) Close() {
+	s.closeOnce.Do(func() {
+		s.backgroundCancel()
+		s.backgroundWg.Wait()
+		s.logger.Info("Service closed")
+	})
 }
 
 func NewService(config ServiceConfig) (*Service, error) {
@@ -308,45 +1033,265 @@ func NewService(config ServiceConfig) (*Service, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tekton client: %w", err)
 	}
+	tektonVersion := config.TektonAPIVersion
+	if tektonVersion == "" {
+		tektonVersion = TektonAPIVersionAuto
+	}
+	tektonVersion, err = resolveTektonAPIVersion(tektonClient.Discovery(), tektonVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the Tekton API version: %w", err)
+	}
 	crtlClient, err := k8s.NewControllerRuntimeClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create controller-runtime client: %w", err)
 	}
 	return NewServiceWithDependencies(
 		&realK8sClient{client: k8sClient},
-		&realTektonClient{client: tektonClient},
+		&realTektonClient{client: tektonClient, apiVersion: tektonVersion},
 		&realControllerRuntimeClient{client: crtlClient},
-		&zapLogger{l: gozap.NewExample()},
+		&zapLogger{l: withLogSampling(gozap.NewExample(), config.LogSamplingInitial, config.LogSamplingThereafter)},
 		config,
 	), nil
 }
 
+// AcceptedResource is the kind of resource the service processes. Events for
+// any other resource are ignored, so the event source only needs to send
+// these. It can be changed with SNAPSHOT_API_VERSION and SNAPSHOT_KIND.
+func (s *Service) AcceptedResource() schema.GroupVersionKind {
+	return s.acceptedGVK
+}
+
+// acceptsResource reports whether an event's resource matches AcceptedResource
+func (s *Service) acceptsResource(apiVersion, kind string) bool {
+	accepted := s.AcceptedResource()
+	return apiVersion == accepted.GroupVersion().String() && kind == accepted.Kind
+}
+
+// eventResult is the outcome of handling an event
+type eventResult string
+
+const (
+	// resultProcessed means the event was acted on and a TaskRun created
+	resultProcessed eventResult = "processed"
+	// resultIgnored means the event was deliberately not acted on, such as
+	// an event for another resource or a snapshot without a ReleasePlan
+	resultIgnored eventResult = "ignored"
+	// resultFailed means handling failed and the event should be redelivered
+	resultFailed eventResult = "failed"
+	// resultDeadLettered means handling failed too many times, so the event
+	// was given up on rather than redelivered again
+	resultDeadLettered eventResult = "dead_lettered"
+)
+
+// handleCloudEvent is the CloudEvents handler. Processed, ignored and dead
+// lettered events are acknowledged, failed events return an error so they're
+// redelivered.
 func (s *Service) handleCloudEvent(ctx context.Context, event cloudevents.Event) error {
+	result, err := s.handleEvent(ctx, event)
+	result, err = s.trackDelivery(ctx, event, result, err)
+	eventsHandled.WithLabelValues(string(result)).Inc()
+	return resultError(result, err)
+}
+
+// resultError maps an event result to the handler's return value
+func resultError(result eventResult, err error) error {
+	switch result {
+	case resultProcessed, resultIgnored, resultDeadLettered:
+		return nil
+	default:
+		if err == nil {
+			err = errors.New("event processing failed")
+		}
+		return err
+	}
+}
+
+func (s *Service) handleEvent(ctx context.Context, event cloudevents.Event) (result eventResult, err error) {
 	s.logger.Info("Received CloudEvent", gozap.String("type", event.Type()))
+	// The SDK hands us the receiver's context rather than the request's, so
+	// the request timeout is applied here as well as in the HTTP middleware
+	if s.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.requestTimeout)
+		defer cancel()
+	}
+	ctx = withEventOrigin(ctx, event)
 	var eventData CloudEventData
-	if err := event.DataAs(&eventData); err != nil {
-		return fmt.Errorf("failed to parse event data: %w", err)
+	if err := s.decodeEventData(event, &eventData); err != nil {
+		return resultFailed, fmt.Errorf("failed to parse event data: %w", err)
 	}
-	if eventData.Kind != "Snapshot" || eventData.APIVersion != "appstudio.redhat.com/v1alpha1" {
+	if !s.acceptsResource(eventData.APIVersion, eventData.Kind) {
 		s.logger.Info("Ignoring resource", gozap.String("apiVersion", eventData.APIVersion), gozap.String("kind", eventData.Kind))
-		return nil
+		return resultIgnored, nil
 	}
-	s.logger.Info("Processing Snapshot", gozap.String("name", eventData.Metadata.Name), gozap.String("namespace", eventData.Metadata.Namespace))
-	snapshot := &konflux.Snapshot{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      eventData.Metadata.Name,
-			Namespace: eventData.Metadata.Namespace,
-		},
+	if s.eventLog != nil {
+		key, appendErr := s.eventLog.append(event)
+		if appendErr != nil {
+			return resultFailed, fmt.Errorf("failed to write event to event log: %w", appendErr)
+		}
+		// Failed events are left in the log to be replayed on the next
+		// startup, in case they aren't redelivered
+		defer func() {
+			if removeErr := s.eventLog.release(key, result != resultFailed); removeErr != nil {
+				s.logger.Error(removeErr, "Failed to remove event from event log", gozap.String("id", event.ID()))
+			}
+		}()
+	}
+	name, namespace := eventData.objectName()
+	s.logger.Info("Processing Snapshot", gozap.String("name", name), gozap.String("namespace", namespace))
+	var snapshot *konflux.Snapshot
+	if s.eventMode == EventModeReference || (s.eventMode == EventModeAuto && eventData.isReference()) {
+		snapshot, err = s.fetchSnapshot(ctx, namespace, name)
+		if apierrors.IsNotFound(err) {
+			s.logger.Info("Ignoring Snapshot deleted before it was processed", gozap.String("name", name), gozap.String("namespace", namespace))
+			snapshotsSkipped.WithLabelValues(skipReasonSnapshotDeleted).Inc()
+			return resultIgnored, nil
+		}
+		if err != nil {
+			return resultFailed, err
+		}
+	} else {
+		snapshot = &konflux.Snapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      eventData.Metadata.Labels,
+				Annotations: eventData.Metadata.Annotations,
+			},
+		}
+		// Assign the raw spec data directly
+		snapshot.Spec = eventData.Spec
 	}
-	// Assign the raw spec data directly
-	snapshot.Spec = eventData.Spec
 	return s.processSnapshot(ctx, snapshot)
 }
 
-func (s *Service) processSnapshot(ctx context.Context, snapshot *konflux.Snapshot) error {
+// fetchSnapshot gets the snapshot an event refers to. It's read as
+// unstructured so it works for whichever resource the service accepts.
+func (s *Service) fetchSnapshot(ctx context.Context, namespace, name string) (*konflux.Snapshot, error) {
+	s.logger.Info("Fetching Snapshot referenced by event", gozap.String("name", name), gozap.String("namespace", namespace))
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(s.AcceptedResource())
+	if err := s.crtlClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get snapshot %s/%s: %w", namespace, name, err)
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot %s/%s: %w", namespace, name, err)
+	}
+	snapshot := &konflux.Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %s/%s: %w", namespace, name, err)
+	}
+	return snapshot, nil
+}
+
+// skipAnnotation set to "true" on a Snapshot excludes it from verification
+const skipAnnotation = "conforma.dev/skip"
+
+// Outcomes reported in the process summary log
+const (
+	outcomeCreated = "created"
+	outcomeSkipped = "skipped"
+	outcomeFailed  = "failed"
+)
+
+// Reasons a snapshot is skipped, reported in the process summary and as the
+// reason label of snapshots_skipped_total
+const (
+	skipReasonAnnotation      = "skip_annotation"
+	skipReasonNoReleasePlan   = "no_release_plan"
+	skipReasonTaskRunReused   = "taskrun_reused"
+	skipReasonSnapshotDeleted = "snapshot_deleted"
+	skipReasonEmptySnapshot   = "empty_snapshot"
+	skipReasonNoApplication   = "no_application"
+	skipReasonDryRun          = "dry_run"
+)
+
+// processSummary collects the decisions made while processing a snapshot so
+// they can be logged as a single entry
+type processSummary struct {
+	snapshot        *konflux.Snapshot
+	application     string
+	configNamespace string
+	configCached    bool
+	policy          string
+	skipReason      string
+	taskRunName     string
+	outcome         string
+	startTime       time.Time
+}
+
+func newProcessSummary(snapshot *konflux.Snapshot, startTime time.Time) *processSummary {
+	// A bad spec is reported by createTaskRun, here it only leaves the
+	// application blank
+	application, _ := snapshot.ApplicationName()
+	return &processSummary{
+		snapshot:    snapshot,
+		application: application,
+		outcome:     outcomeFailed,
+		startTime:   startTime,
+	}
+}
+
+// skip records that the snapshot was deliberately not verified
+func (p *processSummary) skip(reason string) {
+	p.outcome = outcomeSkipped
+	p.skipReason = reason
+	snapshotsSkipped.WithLabelValues(reason).Inc()
+}
+
+// fields returns the summary as log fields. Values that weren't decided are
+// logged as null so every summary has the same keys.
+func (p *processSummary) fields() []gozap.Field {
+	optional := func(key, value string) gozap.Field {
+		if value == "" {
+			return gozap.Stringp(key, nil)
+		}
+		return gozap.String(key, value)
+	}
+	return []gozap.Field{
+		gozap.String("snapshot", p.snapshot.Name),
+		gozap.String("snapshotNamespace", p.snapshot.Namespace),
+		optional("application", p.application),
+		optional("configNamespace", p.configNamespace),
+		gozap.Bool("configCached", p.configCached),
+		optional("policy", p.policy),
+		optional("skipReason", p.skipReason),
+		optional("taskRun", p.taskRunName),
+		gozap.Duration("duration", time.Since(p.startTime)),
+		gozap.String("outcome", p.outcome),
+	}
+}
+
+func (s *Service) processSnapshot(ctx context.Context, snapshot *konflux.Snapshot) (result eventResult, err error) {
 	startTime := time.Now()
 	s.logger.Info("Starting to process snapshot", gozap.String("name", snapshot.Name), gozap.String("namespace", snapshot.Namespace))
 
+	if s.processTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.processTimeout)
+		defer cancel()
+		defer func() {
+			if result == resultFailed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("processing snapshot %s/%s took longer than %s: %w", snapshot.Namespace, snapshot.Name, s.processTimeout, err)
+			}
+		}()
+	}
+
+	summary := newProcessSummary(snapshot, startTime)
+	defer func() {
+		s.logger.Info("Snapshot processing summary", summary.fields()...)
+		s.namespaceStats.record(snapshot.Namespace, summary.outcome)
+		s.recordAudit(summary)
+	}()
+
+	if snapshot.Annotations[skipAnnotation] == "true" {
+		s.logger.Info("Skipping snapshot with skip annotation",
+			gozap.String("name", snapshot.Name), gozap.String("annotation", skipAnnotation))
+		summary.skip(skipReasonAnnotation)
+		return resultIgnored, nil
+	}
+
 	// Read service namespace from environment variable
 	configNamespace := os.Getenv("POD_NAMESPACE")
 	if configNamespace == "" {
@@ -356,30 +1301,94 @@ func (s *Service) processSnapshot(ctx context.Context, snapshot *konflux.Snapsho
 		s.logger.Info("Using POD_NAMESPACE env var for namespace", gozap.String("namespace", configNamespace))
 	}
 
-	config, err := s.readConfigMap(ctx, configNamespace)
+	summary.configNamespace = configNamespace
+
+	config, cached, prefetched, err := s.readConfigAndPrefetchEcp(ctx, configNamespace, snapshot)
 	if err != nil {
 		s.logger.Error(err, "Failed to read configmap")
-		return fmt.Errorf("failed to read configmap: %w", err)
+		return resultFailed, fmt.Errorf("failed to read configmap: %w", err)
 	}
+	if prefetched != nil {
+		ctx = withPrefetchedEcp(ctx, prefetched)
+	}
+	summary.configCached = cached
 	s.logger.Info("Successfully read configmap", gozap.String("namespace", configNamespace))
-	taskRun, err := s.createTaskRun(snapshot, config, configNamespace)
+	if config.VerifyNamespaceExists == "true" {
+		if err := s.verifyNamespaceExists(ctx, config, snapshot.Namespace); err != nil {
+			s.logger.Error(err, "Snapshot namespace check failed")
+			return resultFailed, err
+		}
+	}
+	if config.ProcessEmptySnapshots != "true" {
+		// A spec that can't be parsed is reported by createTaskRun
+		if spec, err := konflux.ParseSnapshotSpec(snapshot.Spec); err == nil && len(spec.Components) == 0 {
+			s.logger.Info("Skipping snapshot without components", gozap.String("name", snapshot.Name))
+			summary.skip(skipReasonEmptySnapshot)
+			return resultIgnored, nil
+		}
+	}
+	if config.ReuseSucceededTaskRuns == "true" {
+		prior, err := s.findSucceededTaskRun(ctx, configNamespace, snapshot)
+		if err != nil {
+			// Not being able to check only costs a redundant TaskRun
+			s.logger.Warn("Unable to look for a prior TaskRun, creating a new one", gozap.Error(err))
+		} else if prior != nil {
+			s.logger.Info("Reusing result of prior Succeeded TaskRun",
+				gozap.String("snapshot", snapshot.Name),
+				gozap.String("resourceVersion", snapshot.ResourceVersion),
+				gozap.String("taskrunName", prior.Name))
+			summary.skip(skipReasonTaskRunReused)
+			summary.taskRunName = prior.Name
+			s.recordProcessSuccess()
+			return resultIgnored, nil
+		}
+	}
+	taskRun, err := s.createTaskRun(ctx, snapshot, config, configNamespace)
 	if err != nil {
 		s.logger.Error(err, "Failed to create taskrun")
-		return fmt.Errorf("failed to create taskrun: %w", err)
+		return resultFailed, fmt.Errorf("failed to create taskrun: %w", err)
 	}
 	if taskRun == nil {
-		// No error was returned, but also no TaskRun was created.
-		// Consider it processed successfully.
+		// No error was returned, but also no TaskRun was created, so
+		// there's nothing to do for this snapshot
 		totalDuration := time.Since(startTime)
 		s.logger.Info("No VSA creation needed for this snapshot",
 			gozap.Duration("processing_duration_ms", totalDuration))
-		return nil
+		if appName, err := snapshot.ApplicationName(); err == nil && appName == "" {
+			// Without an application the ReleasePlan couldn't be looked for,
+			// see resolvePolicy
+			summary.skip(skipReasonNoApplication)
+		} else {
+			summary.skip(skipReasonNoReleasePlan)
+		}
+		s.recordProcessSuccess()
+		return resultIgnored, nil
+	}
+	if origin, ok := eventOriginFrom(ctx); ok {
+		stampEventOrigin(taskRun, origin)
 	}
+	stampTraceparent(ctx, taskRun)
 	s.logger.Info("Successfully created taskrun spec", gozap.String("taskrunName", taskRun.Name))
+	for _, param := range taskRun.Spec.Params {
+		if param.Name == "POLICY_CONFIGURATION" {
+			summary.policy = param.Value.StringVal
+		}
+	}
+	// createTaskRun has validated the mode
+	if mode, _ := dryRunMode(config); mode == dryRunLog {
+		s.logger.Info("Dry run, not creating TaskRun",
+			gozap.String("name", taskRun.Name),
+			gozap.String("namespace", configNamespace),
+			gozap.String("snapshot", snapshot.Name),
+			gozap.Any("labels", taskRun.Labels))
+		summary.skip(skipReasonDryRun)
+		s.recordProcessSuccess()
+		return resultIgnored, nil
+	}
 
 	// Create TaskRun with retry logic and configurable timeout
 	var createdTaskRun *tektonv1.TaskRun
-	err = s.retryWithBackoff(config, "create-taskrun", func() error {
+	err = s.retryWithBackoff(ctx, config, "create-taskrun", func() error {
 		// Add timeout for Tekton API call (configurable)
 		timeoutSeconds := 5 // Default
 		if config.TektonTimeoutSeconds != "" {
@@ -391,12 +1400,32 @@ func (s *Service) processSnapshot(ctx context.Context, snapshot *konflux.Snapsho
 		defer cancel()
 
 		var createErr error
-		createdTaskRun, createErr = s.tektonClient.TektonV1().TaskRuns(configNamespace).Create(trCtx, taskRun, metav1.CreateOptions{})
+		taskRuns := s.tektonClient.TektonV1().TaskRuns(configNamespace)
+		if config.ApplyTaskRuns == "true" {
+			// The service owns every field it sets, so conflicts with
+			// other managers are overridden
+			createdTaskRun, createErr = taskRuns.Apply(trCtx, taskRun, metav1.ApplyOptions{FieldManager: managedByValue, Force: true})
+		} else {
+			createdTaskRun, createErr = taskRuns.Create(trCtx, taskRun, metav1.CreateOptions{})
+		}
 		return createErr
 	})
+	if apierrors.IsAlreadyExists(err) {
+		// A redelivered event, or a retry after a create whose response was
+		// lost, ends up with the same name. The TaskRun is already there so
+		// there's nothing left to do.
+		s.logger.Info("TaskRun already exists, treating it as created",
+			gozap.String("name", taskRun.Name),
+			gozap.String("namespace", configNamespace),
+			gozap.String("snapshot", snapshot.Name))
+		summary.outcome = outcomeCreated
+		summary.taskRunName = taskRun.Name
+		s.recordProcessSuccess()
+		return resultProcessed, nil
+	}
 	if err != nil {
 		s.logger.Error(err, "Failed to create taskrun in cluster after retries")
-		return fmt.Errorf("failed to create taskrun in cluster after retries: %w", err)
+		return resultFailed, fmt.Errorf("failed to create taskrun in cluster after retries: %w", err)
 	}
 
 	// Log performance metrics
@@ -406,99 +1435,531 @@ func (s *Service) processSnapshot(ctx context.Context, snapshot *konflux.Snapsho
 		gozap.String("namespace", createdTaskRun.Namespace),
 		gozap.String("snapshot", snapshot.Name),
 		gozap.Duration("processing_duration_ms", totalDuration))
+	summary.outcome = outcomeCreated
+	summary.taskRunName = createdTaskRun.Name
+	s.recordProcessSuccess()
+	s.emitTaskRunCreated(ctx, snapshot, createdTaskRun, summary.policy)
+	return resultProcessed, nil
+}
+
+// findSucceededTaskRun returns a managed TaskRun that Succeeded for this
+// version of the snapshot, or nil if there isn't one. Snapshots without a
+// resourceVersion never match.
+func (s *Service) findSucceededTaskRun(ctx context.Context, namespace string, snapshot *konflux.Snapshot) (*tektonv1.TaskRun, error) {
+	if snapshotVersionLabelValue(snapshot) == "" {
+		return nil, nil
+	}
+	selector := fmt.Sprintf("%s=%s,%s=%s,%s=%s",
+		managedByLabel, managedByValue,
+		instanceLabel, snapshot.Name,
+		snapshotVersionLabel, snapshot.ResourceVersion)
+	list, err := s.tektonClient.TektonV1().TaskRuns(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TaskRuns in namespace %s: %w", namespace, err)
+	}
+	for i := range list.Items {
+		taskRun := &list.Items[i]
+		if taskRun.Labels[instanceLabel] == snapshot.Name &&
+			taskRun.Labels[snapshotVersionLabel] == snapshot.ResourceVersion &&
+			taskRun.IsSuccessful() {
+			return taskRun, nil
+		}
+	}
+	return nil, nil
+}
+
+// snapshotVersionLabelValue returns the snapshot's resourceVersion if it can
+// be used as a label value, or "" if not
+func snapshotVersionLabelValue(snapshot *konflux.Snapshot) string {
+	if snapshot.ResourceVersion == "" || len(validation.IsValidLabelValue(snapshot.ResourceVersion)) > 0 {
+		return ""
+	}
+	return snapshot.ResourceVersion
+}
+
+// Outbound event sent after a TaskRun is created
+const (
+	taskRunCreatedEventType   = "dev.conforma.taskrun.created"
+	taskRunCreatedEventSource = "conforma-knative-service"
+)
+
+// TaskRunCreatedEventData is the payload of the taskrun.created event
+type TaskRunCreatedEventData struct {
+	Snapshot          string `json:"snapshot"`
+	SnapshotNamespace string `json:"snapshotNamespace"`
+	TaskRun           string `json:"taskRun"`
+	TaskRunNamespace  string `json:"taskRunNamespace"`
+	Policy            string `json:"policy"`
+}
+
+// emitTaskRunCreated notifies the event sink of a new TaskRun. The TaskRun
+// has already been created so failures are only logged.
+func (s *Service) emitTaskRunCreated(ctx context.Context, snapshot *konflux.Snapshot, taskRun *tektonv1.TaskRun, policy string) {
+	if s.eventSender == nil {
+		return
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetType(taskRunCreatedEventType)
+	event.SetSource(taskRunCreatedEventSource)
+	event.SetSubject(taskRun.Name)
+	data := TaskRunCreatedEventData{
+		Snapshot:          snapshot.Name,
+		SnapshotNamespace: snapshot.Namespace,
+		TaskRun:           taskRun.Name,
+		TaskRunNamespace:  taskRun.Namespace,
+		Policy:            policy,
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		s.logger.Error(err, "Failed to encode TaskRun created event")
+		return
+	}
+
+	if err := s.eventSender.Send(ctx, event); err != nil {
+		s.logger.Error(err, "Failed to send TaskRun created event",
+			gozap.String("taskRun", taskRun.Name),
+			gozap.String("snapshot", snapshot.Name))
+		return
+	}
+	s.logger.Info("Sent TaskRun created event", gozap.String("taskRun", taskRun.Name))
+}
+
+// verifyNamespaceExists checks the namespace is still there, since it may
+// have been deleted between the event being sent and it being processed. A
+// missing namespace isn't retried and the returned error wraps the NotFound
+// error.
+func (s *Service) verifyNamespaceExists(ctx context.Context, config *TaskRunConfig, namespace string) error {
+	err := s.retryK8sWithBackoff(ctx, config, "get-namespace", func() error {
+		_, getErr := s.k8sClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		return getErr
+	})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("namespace %s no longer exists: %w", namespace, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check namespace %s exists: %w", namespace, err)
+	}
 	return nil
 }
 
+// recordProcessSuccess notes the time of the latest successfully processed
+// snapshot so that a stalled service can be alerted on
+func (s *Service) recordProcessSuccess() {
+	now := time.Now()
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.lastSuccess = now
+	lastSuccessfulProcessTimestamp.Set(float64(now.Unix()))
+}
+
+// LastSuccess returns when a snapshot was last processed successfully, or the
+// zero time if none has been yet
+func (s *Service) LastSuccess() time.Time {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	return s.lastSuccess
+}
+
+// recordConfigRead notes that a config map was read successfully
+func (s *Service) recordConfigRead() {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.configReadyOnce = true
+}
+
+// ConfigReady reports whether a config map has been read successfully, which
+// is when the service is ready for events
+func (s *Service) ConfigReady() bool {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	return s.configReadyOnce
+}
+
+// startConfigReadinessCheck reads the config map in the namespace until a
+// read succeeds, so the service becomes ready without waiting for an event
+// that it wouldn't be sent while unready
+func (s *Service) startConfigReadinessCheck(namespace string, interval time.Duration) {
+	s.runInBackground(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			_, err := s.readConfigMap(ctx, namespace)
+			if err == nil {
+				s.logger.Info("Config map is readable, service is ready", gozap.String("namespace", namespace))
+				return
+			}
+			s.logger.Warn("Config map is not readable yet, service is not ready",
+				gozap.String("namespace", namespace), gozap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
 func (s *Service) readConfigMap(ctx context.Context, namespace string) (*TaskRunConfig, error) {
+	config, _, err := s.readConfigMapCached(ctx, namespace)
+	return config, err
+}
+
+// readConfigMapCached is readConfigMap that also reports whether the config
+// came from the cache
+func (s *Service) readConfigMapCached(ctx context.Context, namespace string) (*TaskRunConfig, bool, error) {
 	// Check cache first
 	cachedConfig, found := s.configCache.get(namespace)
 	if found {
 		s.logger.Info("Using cached config for namespace", gozap.String("namespace", namespace))
-		return cachedConfig, nil
+		return cachedConfig, true, nil
 	}
 
 	// If not in cache, fetch from K8s
-	configMap, err := s.k8sClient.CoreV1().ConfigMaps(namespace).Get(ctx, s.configMapName, metav1.GetOptions{})
+	data, err := s.fetchConfigMapData(ctx, namespace)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get configmap %s: %w", s.configMapName, err)
+		return nil, false, err
+	}
+	config := s.parseTaskRunConfig(data)
+	s.recordConfigRead()
+
+	// The config map's CACHE_TTL_MINUTES replaces the TTL from the
+	// environment. Expiry is checked on get, so the new TTL covers this
+	// entry as well as those already cached.
+	s.updateCacheTTL(config)
+
+	// Cache the fetched config
+	s.configCache.set(namespace, config)
+	s.logger.Info("Fetched and cached config for namespace", gozap.String("namespace", namespace))
+	return config, false, nil
+}
+
+// fetchConfigMapData reads the config map data in the namespace. With
+// CONFIG_MAP_NAMES the config maps are merged in order, so a key in a later
+// one overrides the same key in an earlier one.
+func (s *Service) fetchConfigMapData(ctx context.Context, namespace string) (map[string]string, error) {
+	names := s.configMapNames
+	if len(names) == 0 {
+		names = []string{s.configMapName}
+	}
+
+	data := map[string]string{}
+	found := 0
+	for _, name := range names {
+		configMap, err := s.k8sClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) && s.ignoreMissingConfigMaps && len(names) > 1 {
+			s.logger.Warn("Config map not found, skipping it",
+				gozap.String("name", name), gozap.String("namespace", namespace))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+		maps.Copy(data, configMap.Data)
+		found++
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("none of the configmaps %s were found", strings.Join(names, ", "))
 	}
+	return data, nil
+}
+
+// parseTaskRunConfig reads the config map data into a TaskRunConfig. Boolean
+// and integer values are normalized, see normalizeBoolConfig.
+func (s *Service) parseTaskRunConfig(data map[string]string) *TaskRunConfig {
 	config := &TaskRunConfig{}
-	if val, exists := configMap.Data["POLICY_CONFIGURATION"]; exists {
+	if val, exists := data["POLICY_CONFIGURATION"]; exists {
 		config.PolicyConfiguration = val
 	}
-	if val, exists := configMap.Data["PUBLIC_KEY"]; exists {
+	if val, exists := data["PUBLIC_KEY"]; exists {
 		config.PublicKey = val
 	}
-	if val, exists := configMap.Data["IGNORE_REKOR"]; exists {
+	if val, exists := data["IGNORE_REKOR"]; exists {
 		config.IgnoreRekor = val
 	}
-	if val, exists := configMap.Data["VSA_SIGNING_KEY_SECRET_NAME"]; exists {
+	if val, exists := data["VSA_SIGNING_KEY_SECRET_NAME"]; exists {
 		config.VsaSigningKeySecretName = val
 	}
-	if val, exists := configMap.Data["VSA_UPLOAD_URL"]; exists {
+	if val, exists := data["VSA_UPLOAD_URL"]; exists {
 		config.VsaUploadUrl = val
 	}
-	if val, exists := configMap.Data["TASK_NAME"]; exists {
+	if val, exists := data["VSA_UPLOAD_URL_SECRET_NAME"]; exists {
+		config.VsaUploadUrlSecretName = val
+	}
+	if val, exists := data["VSA_UPLOAD_URL_SECRET_KEY"]; exists {
+		config.VsaUploadUrlSecretKey = val
+	}
+	if val, exists := data["ALLOWED_UPLOAD_SCHEMES"]; exists {
+		config.AllowedUploadSchemes = val
+	}
+	if val, exists := data["ALLOWED_IMAGE_REGISTRIES"]; exists {
+		config.AllowedImageRegistries = val
+	}
+	if val, exists := data["TASK_NAME"]; exists {
 		config.TaskName = val
 	}
-	if val, exists := configMap.Data["STRICT"]; exists {
-		config.Strict = val
+	if val, exists := data["TASK_NAMESPACE"]; exists {
+		config.TaskNamespace = val
+	}
+	if val, exists := data["TASK_KIND"]; exists {
+		config.TaskKind = val
+	}
+	if val, exists := data["RELEASEPLAN_AMBIGUITY_MODE"]; exists {
+		config.ReleasePlanAmbiguityMode = val
 	}
-	if val, exists := configMap.Data["WORKERS"]; exists {
-		config.Workers = val
+	if val, exists := data["RELEASEPLAN_SEARCH_NAMESPACES"]; exists {
+		config.ReleasePlanSearchNamespaces = val
 	}
-	if val, exists := configMap.Data["DEBUG"]; exists {
-		config.Debug = val
+	if val, exists := data["MATCH_RELEASEPLAN_BY_TARGET"]; exists {
+		config.MatchReleasePlanByTarget = s.normalizeBoolConfig("MATCH_RELEASEPLAN_BY_TARGET", val)
 	}
-	if val, exists := configMap.Data["CACHE_TTL_MINUTES"]; exists {
-		config.CacheTTLMinutes = val
+	if val, exists := data["PROCESS_EMPTY_SNAPSHOTS"]; exists {
+		config.ProcessEmptySnapshots = s.normalizeBoolConfig("PROCESS_EMPTY_SNAPSHOTS", val)
 	}
-	if val, exists := configMap.Data["TEKTON_TIMEOUT_SECONDS"]; exists {
+	if val, exists := data["APPLY_TASKRUNS"]; exists {
+		config.ApplyTaskRuns = s.normalizeBoolConfig("APPLY_TASKRUNS", val)
+	}
+	if val, exists := data["ANNOTATE_SNAPSHOT_DISPLAY_INFO"]; exists {
+		config.AnnotateSnapshotDisplayInfo = s.normalizeBoolConfig("ANNOTATE_SNAPSHOT_DISPLAY_INFO", val)
+	}
+	if val, exists := data["REUSE_SUCCEEDED_TASKRUNS"]; exists {
+		config.ReuseSucceededTaskRuns = s.normalizeBoolConfig("REUSE_SUCCEEDED_TASKRUNS", val)
+	}
+	if val, exists := data["VERIFY_NAMESPACE_EXISTS"]; exists {
+		config.VerifyNamespaceExists = s.normalizeBoolConfig("VERIFY_NAMESPACE_EXISTS", val)
+	}
+	if val, exists := data["VERIFY_POLICY_EXISTS"]; exists {
+		config.VerifyPolicyExists = s.normalizeBoolConfig("VERIFY_POLICY_EXISTS", val)
+	}
+	if val, exists := data["STRICT"]; exists {
+		config.Strict = s.normalizeBoolConfig("STRICT", val)
+	}
+	if val, exists := data["WORKERS"]; exists {
+		config.Workers = s.normalizeSignedIntConfig("WORKERS", val)
+	}
+	if val, exists := data["DEFAULT_WORKERS"]; exists {
+		config.DefaultWorkers = s.normalizeSignedIntConfig("DEFAULT_WORKERS", val)
+	}
+	if val, exists := data["MAX_WORKERS"]; exists {
+		config.MaxWorkers = s.normalizeIntConfig("MAX_WORKERS", val)
+	}
+	if val, exists := data["DEBUG"]; exists {
+		config.Debug = s.normalizeBoolConfig("DEBUG", val)
+	}
+	if val, exists := data["CACHE_TTL_MINUTES"]; exists {
+		config.CacheTTLMinutes = s.normalizeIntConfig("CACHE_TTL_MINUTES", val)
+	}
+	if val, exists := data["TEKTON_TIMEOUT_SECONDS"]; exists {
 		config.TektonTimeoutSeconds = val
 	}
-	if val, exists := configMap.Data["VSA_EXPIRATION_HOURS"]; exists {
+	if val, exists := data["VSA_EXPIRATION_HOURS"]; exists {
 		config.VsaExpirationHours = val
 	}
-	if val, exists := configMap.Data["TEKTON_RETRY_ATTEMPTS"]; exists {
+	if val, exists := data["TEKTON_RETRY_ATTEMPTS"]; exists {
 		config.TektonRetryAttempts = val
 	}
-	if val, exists := configMap.Data["TEKTON_RETRY_DELAY_SECONDS"]; exists {
+	if val, exists := data["TEKTON_RETRY_DELAY_SECONDS"]; exists {
 		config.TektonRetryDelaySeconds = val
 	}
-	if val, exists := configMap.Data["K8S_RETRY_ATTEMPTS"]; exists {
+	if val, exists := data["K8S_RETRY_ATTEMPTS"]; exists {
 		config.K8sRetryAttempts = val
 	}
-	if val, exists := configMap.Data["K8S_RETRY_DELAY_SECONDS"]; exists {
+	if val, exists := data["K8S_RETRY_DELAY_SECONDS"]; exists {
 		config.K8sRetryDelaySeconds = val
 	}
-	if val, exists := configMap.Data["CIRCUIT_BREAKER_THRESHOLD"]; exists {
+	if val, exists := data["CIRCUIT_BREAKER_THRESHOLD"]; exists {
 		config.CircuitBreakerThreshold = val
 	}
-	if val, exists := configMap.Data["CIRCUIT_BREAKER_TIMEOUT_SECONDS"]; exists {
+	if val, exists := data["CIRCUIT_BREAKER_TIMEOUT_SECONDS"]; exists {
 		config.CircuitBreakerTimeout = val
 	}
-	if val, exists := configMap.Data["TASK_CPU_REQUEST"]; exists {
+	if val, exists := data["CIRCUIT_BREAKER_FAIL_MODE"]; exists {
+		config.CircuitBreakerFailMode = val
+	}
+	if val, exists := data["CIRCUIT_BREAKER_PROBE_INTERVAL_SECONDS"]; exists {
+		config.CircuitBreakerProbeInterval = val
+	}
+	if val, exists := data["CIRCUIT_BREAKER_PROBES"]; exists {
+		config.CircuitBreakerProbes = val
+	}
+	if val, exists := data["TASK_CPU_REQUEST"]; exists {
 		config.TaskCpuRequest = val
 	}
-	if val, exists := configMap.Data["TASK_MEMORY_REQUEST"]; exists {
+	if val, exists := data["TASK_MEMORY_REQUEST"]; exists {
 		config.TaskMemoryRequest = val
 	}
-	if val, exists := configMap.Data["TASK_MEMORY_LIMIT"]; exists {
+	if val, exists := data["TASK_MEMORY_LIMIT"]; exists {
 		config.TaskMemoryLimit = val
 	}
+	if val, exists := data["TASKRUN_ENV"]; exists {
+		config.TaskRunEnv = val
+	}
+	if val, exists := data["TASKRUN_EXTRA_PARAMS"]; exists {
+		config.TaskRunExtraParams = val
+	}
+	if val, exists := data["TASKRUN_PRIORITY_CLASS"]; exists {
+		config.TaskRunPriorityClass = strings.TrimSpace(val)
+	}
+	if val, exists := data["TASKRUN_NAME_PREFIX"]; exists {
+		config.TaskRunNamePrefix = strings.TrimSpace(val)
+	}
+	if val, exists := data["APPLY_RESTRICTED_SECURITY_CONTEXT"]; exists {
+		config.ApplyRestrictedSecurityContext = s.normalizeBoolConfig("APPLY_RESTRICTED_SECURITY_CONTEXT", val)
+	}
+	if val, exists := data["SCRATCH_WORKSPACE_NAME"]; exists {
+		config.ScratchWorkspaceName = val
+	}
+	if val, exists := data["SCRATCH_WORKSPACE_TYPE"]; exists {
+		config.ScratchWorkspaceType = val
+	}
+	if val, exists := data["SCRATCH_WORKSPACE_CLAIM_NAME"]; exists {
+		config.ScratchWorkspaceClaimName = val
+	}
+	if val, exists := data["LOG_STREAMING_ANNOTATION_KEY"]; exists {
+		config.LogStreamingAnnotationKey = val
+	}
+	if val, exists := data["LOG_STREAMING_ANNOTATION_VALUE"]; exists {
+		config.LogStreamingAnnotationValue = val
+	}
+	if val, exists := data["PROPAGATE_ANNOTATION_PREFIXES"]; exists {
+		config.PropagateAnnotationPrefixes = val
+	}
+	if val, exists := data["COMPONENT_IMAGE_FIELD"]; exists {
+		config.ComponentImageField = strings.TrimSpace(val)
+	}
+	if val, exists := data["PIPELINERUN_LABEL_KEY"]; exists {
+		config.PipelineRunLabelKey = strings.TrimSpace(val)
+	}
+	if val, exists := data["APPLICATION_POLICY_OVERRIDES"]; exists {
+		config.ApplicationPolicyOverrides = val
+	}
+	if val, exists := data["POLICY_RESOLUTION_ORDER"]; exists {
+		config.PolicyResolutionOrder = val
+	}
+	if val, exists := data["DRY_RUN_MODE"]; exists {
+		config.DryRunMode = val
+	}
+	if val, exists := data["MISSING_APPLICATION_MODE"]; exists {
+		config.MissingApplicationMode = val
+	}
+	if val, exists := data["RETRY_ON_MISSING_RELEASEPLAN"]; exists {
+		config.RetryOnMissingReleasePlan = s.normalizeBoolConfig("RETRY_ON_MISSING_RELEASEPLAN", val)
+	}
+	if val, exists := data["MISSING_RELEASEPLAN_GRACE_SECONDS"]; exists {
+		config.MissingReleasePlanGraceSeconds = val
+	}
+	return config
+}
 
-	// Cache the fetched config
-	s.configCache.set(namespace, config)
-	s.logger.Info("Fetched and cached config for namespace", gozap.String("namespace", namespace))
-	return config, nil
+// normalizeBoolConfig returns "true" or "false" for a boolean config value.
+// Unparseable values are logged and dropped so the param default is used.
+func (s *Service) normalizeBoolConfig(key, val string) string {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "":
+		return ""
+	case "true", "1":
+		return "true"
+	case "false", "0":
+		return "false"
+	}
+	s.logger.Warn("Ignoring invalid boolean config value, using default",
+		gozap.String("key", key), gozap.String("value", val))
+	return ""
+}
+
+// updateCacheTTL applies the config map's CACHE_TTL_MINUTES to the cache if
+// it's set and differs from the current TTL. The cache is shared by all
+// namespaces, so the most recently read value wins.
+func (s *Service) updateCacheTTL(config *TaskRunConfig) {
+	if config.CacheTTLMinutes == "" {
+		return
+	}
+	minutes, err := strconv.Atoi(config.CacheTTLMinutes)
+	if err != nil {
+		return
+	}
+	ttl := time.Duration(minutes) * time.Minute
+	if current := s.configCache.getTTL(); ttl != current {
+		s.configCache.setTTL(ttl)
+		s.logger.Info("Updated config cache TTL from config map",
+			gozap.Duration("previous", current), gozap.Duration("ttl", ttl))
+	}
+}
+
+// normalizeIntConfig returns a positive integer config value in canonical form.
+// Unparseable values are logged and dropped so the param default is used.
+func (s *Service) normalizeIntConfig(key, val string) string {
+	trimmed := strings.TrimSpace(val)
+	if trimmed == "" {
+		return ""
+	}
+	if parsed, err := strconv.Atoi(trimmed); err == nil && parsed > 0 {
+		return strconv.Itoa(parsed)
+	}
+	s.logger.Warn("Ignoring invalid integer config value, using default",
+		gozap.String("key", key), gozap.String("value", val))
+	return ""
+}
+
+// normalizeSignedIntConfig returns an integer config value in canonical
+// form, keeping zero and negative values for the caller to clamp.
+// Unparseable values are logged and dropped so the default is used.
+func (s *Service) normalizeSignedIntConfig(key, val string) string {
+	trimmed := strings.TrimSpace(val)
+	if trimmed == "" {
+		return ""
+	}
+	if parsed, err := strconv.Atoi(trimmed); err == nil {
+		return strconv.Itoa(parsed)
+	}
+	s.logger.Warn("Ignoring invalid integer config value, using default",
+		gozap.String("key", key), gozap.String("value", val))
+	return ""
 }
 
 // Circuit breaker and resilience methods
+
+// circuitBreakerFor returns the circuit breaker for an operation, creating it
+// if needed, so that one failing dependency doesn't block unrelated calls
+func (s *Service) circuitBreakerFor(operation string) *CircuitBreakerState {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	cb, exists := s.circuitBreakers[operation]
+	if !exists {
+		cb = &CircuitBreakerState{}
+		s.circuitBreakers[operation] = cb
+	}
+	return cb
+}
+
+// resetCircuitBreaker closes the circuit breaker for an operation. It returns
+// false if the operation is unknown, i.e. it has never been attempted.
+func (s *Service) resetCircuitBreaker(operation string) bool {
+	s.breakersMu.Lock()
+	_, exists := s.circuitBreakers[operation]
+	s.breakersMu.Unlock()
+
+	if !exists {
+		return false
+	}
+	cb := s.circuitBreakerFor(operation)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	s.closeCircuitBreaker(cb, operation)
+	return true
+}
+
+// circuitBreakerFailOpen is the CIRCUIT_BREAKER_FAIL_MODE that lets operations
+// through an open breaker. Any other value, including the default "closed",
+// blocks them.
+const circuitBreakerFailOpen = "open"
+
 func (s *Service) checkCircuitBreaker(config *TaskRunConfig, operation string) bool {
-	s.circuitBreaker.mu.RLock()
-	defer s.circuitBreaker.mu.RUnlock()
+	cb := s.circuitBreakerFor(operation)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 
-	if !s.circuitBreaker.isOpen {
+	if !cb.isOpen {
 		return false // Circuit is closed, allow operation
 	}
 
@@ -510,24 +1971,55 @@ func (s *Service) checkCircuitBreaker(config *TaskRunConfig, operation string) b
 		}
 	}
 
-	if time.Since(s.circuitBreaker.lastFailure) > time.Duration(timeoutSeconds)*time.Second {
-		s.logger.Info("Circuit breaker timeout expired, allowing operation",
+	if !cb.halfOpen && time.Since(cb.lastFailure) > time.Duration(timeoutSeconds)*time.Second {
+		s.logger.Info("Circuit breaker timeout expired, probing operation",
 			gozap.String("operation", operation))
-		return false // Allow operation to test if service is back
+		cb.halfOpen = true
+		cb.successfulProbes = 0
+		cb.lastProbe = time.Time{}
+	}
+
+	if cb.halfOpen {
+		probeInterval := 0 // Default, probe on every operation
+		if config.CircuitBreakerProbeInterval != "" {
+			if parsed, parseErr := strconv.Atoi(config.CircuitBreakerProbeInterval); parseErr == nil && parsed > 0 {
+				probeInterval = parsed
+			}
+		}
+		if time.Since(cb.lastProbe) >= time.Duration(probeInterval)*time.Second {
+			cb.lastProbe = time.Now()
+			return false // Allow operation to test if service is back
+		}
+	}
+
+	if strings.EqualFold(strings.TrimSpace(config.CircuitBreakerFailMode), circuitBreakerFailOpen) {
+		s.logger.Warn("Circuit breaker is open, allowing operation because fail mode is open",
+			gozap.String("operation", operation),
+			gozap.Int("failures", cb.failures))
+		return false // Rely on retry and backoff instead
 	}
 
 	s.logger.Warn("Circuit breaker is open, blocking operation",
 		gozap.String("operation", operation),
-		gozap.Int("failures", s.circuitBreaker.failures))
+		gozap.Int("failures", cb.failures))
 	return true // Block operation
 }
 
 func (s *Service) recordFailure(config *TaskRunConfig, operation string) {
-	s.circuitBreaker.mu.Lock()
-	defer s.circuitBreaker.mu.Unlock()
+	cb := s.circuitBreakerFor(operation)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	cb.lastFailure = time.Now()
 
-	s.circuitBreaker.failures++
-	s.circuitBreaker.lastFailure = time.Now()
+	if cb.halfOpen {
+		// A failed probe restarts the timeout
+		cb.halfOpen = false
+		s.logger.Warn("Circuit breaker probe failed, reopening",
+			gozap.String("operation", operation),
+			gozap.Int("successful_probes", cb.successfulProbes))
+	}
 
 	threshold := 5 // Default
 	if config.CircuitBreakerThreshold != "" {
@@ -536,42 +2028,76 @@ func (s *Service) recordFailure(config *TaskRunConfig, operation string) {
 		}
 	}
 
-	if s.circuitBreaker.failures >= threshold && !s.circuitBreaker.isOpen {
-		s.circuitBreaker.isOpen = true
+	if cb.failures >= threshold && !cb.isOpen {
+		cb.isOpen = true
 		s.logger.Error(nil, "ALERT: Circuit breaker opened - external service degraded",
 			gozap.String("alert_type", "circuit_breaker_opened"),
 			gozap.String("service", "external_dependency"),
 			gozap.String("operation", operation),
-			gozap.Int("consecutive_failures", s.circuitBreaker.failures),
+			gozap.Int("consecutive_failures", cb.failures),
 			gozap.Int("failure_threshold", threshold),
-			gozap.Time("last_failure", s.circuitBreaker.lastFailure))
+			gozap.Time("last_failure", cb.lastFailure))
 	}
 }
 
-func (s *Service) recordSuccess(operation string) {
-	s.circuitBreaker.mu.Lock()
-	defer s.circuitBreaker.mu.Unlock()
+func (s *Service) recordSuccess(config *TaskRunConfig, operation string) {
+	cb := s.circuitBreakerFor(operation)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.halfOpen {
+		probes := 1 // Default
+		if config.CircuitBreakerProbes != "" {
+			if parsed, parseErr := strconv.Atoi(config.CircuitBreakerProbes); parseErr == nil && parsed > 0 {
+				probes = parsed
+			}
+		}
+		cb.successfulProbes++
+		if cb.successfulProbes < probes {
+			s.logger.Info("Circuit breaker probe succeeded",
+				gozap.String("operation", operation),
+				gozap.Int("successful_probes", cb.successfulProbes),
+				gozap.Int("required_probes", probes))
+			return
+		}
+	}
+
+	s.closeCircuitBreaker(cb, operation)
+}
 
-	if s.circuitBreaker.isOpen {
+// closeCircuitBreaker resets the breaker's state. The caller holds cb.mu.
+func (s *Service) closeCircuitBreaker(cb *CircuitBreakerState, operation string) {
+	if cb.isOpen {
 		s.logger.Info("RECOVERY: Circuit breaker closed - external service recovered",
 			gozap.String("alert_type", "circuit_breaker_closed"),
 			gozap.String("service", "external_dependency"),
 			gozap.String("operation", operation),
-			gozap.Int("previous_failures", s.circuitBreaker.failures),
-			gozap.Duration("downtime_duration", time.Since(s.circuitBreaker.lastFailure)))
+			gozap.Int("previous_failures", cb.failures),
+			gozap.Duration("downtime_duration", time.Since(cb.lastFailure)))
 	}
 
-	// Reset circuit breaker state on success
-	s.circuitBreaker.failures = 0
-	s.circuitBreaker.isOpen = false
+	cb.failures = 0
+	cb.isOpen = false
+	cb.halfOpen = false
+	cb.successfulProbes = 0
 }
 
-func (s *Service) retryWithBackoff(config *TaskRunConfig, operation string, fn func() error) error {
-	// Check circuit breaker first
-	if s.checkCircuitBreaker(config, operation) {
-		return fmt.Errorf("circuit breaker is open for operation: %s", operation)
-	}
+// RetryClassifier reports whether an operation that failed with err should be
+// retried. A custom classifier can defer to isRetryableError for the errors
+// it has no opinion on.
+type RetryClassifier func(err error) bool
+
+// isRetryableError reports whether an operation that failed with err is worth
+// retrying. A missing object won't appear by retrying immediately, so there's
+// no point hammering the API server (or tripping the breaker) for it. Nor
+// will an object that already exists go away.
+func isRetryableError(err error) bool {
+	return !apierrors.IsNotFound(err) && !apierrors.IsAlreadyExists(err)
+}
 
+// retryWithBackoff retries a Tekton API operation using the TEKTON_RETRY_*
+// settings
+func (s *Service) retryWithBackoff(ctx context.Context, config *TaskRunConfig, operation string, fn func() error) error {
 	maxAttempts := 3 // Default
 	if config.TektonRetryAttempts != "" {
 		if parsed, parseErr := strconv.Atoi(config.TektonRetryAttempts); parseErr == nil && parsed > 0 {
@@ -586,216 +2112,1688 @@ func (s *Service) retryWithBackoff(config *TaskRunConfig, operation string, fn f
 		}
 	}
 
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		if err := fn(); err != nil {
-			lastErr = err
-			s.recordFailure(config, operation)
+	return s.retry(ctx, config, operation, maxAttempts, retryDelay, fn)
+}
 
-			if attempt < maxAttempts {
-				s.logger.Warn("Operation failed, retrying",
-					gozap.String("operation", operation),
-					gozap.Int("attempt", attempt),
-					gozap.Int("maxAttempts", maxAttempts),
-					gozap.Duration("retryDelay", retryDelay),
-					gozap.Error(err))
-				time.Sleep(retryDelay)
-				continue
-			}
-			// Final attempt failed
-			s.logger.Error(lastErr, "Operation failed after all retry attempts",
-				gozap.String("operation", operation),
-				gozap.Int("attempts", maxAttempts))
-			return lastErr
+// retryK8sWithBackoff retries a Kubernetes API operation using the
+// K8S_RETRY_* settings
+func (s *Service) retryK8sWithBackoff(ctx context.Context, config *TaskRunConfig, operation string, fn func() error) error {
+	maxAttempts := 3 // Default
+	if config.K8sRetryAttempts != "" {
+		if parsed, parseErr := strconv.Atoi(config.K8sRetryAttempts); parseErr == nil && parsed > 0 {
+			maxAttempts = parsed
+		}
+	}
+
+	retryDelay := 1 * time.Second // Default
+	if config.K8sRetryDelaySeconds != "" {
+		if parsed, parseErr := strconv.Atoi(config.K8sRetryDelaySeconds); parseErr == nil && parsed > 0 {
+			retryDelay = time.Duration(parsed) * time.Second
 		}
-		// Success
-		s.recordSuccess(operation)
-		if attempt > 1 {
+	}
+
+	return s.retry(ctx, config, operation, maxAttempts, retryDelay, fn)
+}
+
+// retryDelayFor returns how long to wait before retrying after err. When the
+// API server is throttling requests and says when to retry, that's waited
+// for instead if it's longer than the configured delay.
+func retryDelayFor(err error, retryDelay time.Duration) time.Duration {
+	if !apierrors.IsTooManyRequests(err) {
+		return retryDelay
+	}
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+		return max(retryDelay, time.Duration(seconds)*time.Second)
+	}
+	return retryDelay
+}
+
+// retry runs fn with retry.Do, retrying errors the service's RetryClassifier
+// deems retryable after a fixed delay. Failures count towards the operation's
+// circuit breaker, unless they're down to ctx running out of time.
+func (s *Service) retry(ctx context.Context, config *TaskRunConfig, operation string, maxAttempts int, retryDelay time.Duration, fn func() error) error {
+	// Check circuit breaker first
+	if s.checkCircuitBreaker(config, operation) {
+		return fmt.Errorf("circuit breaker is open for operation: %s", operation)
+	}
+
+	attempts := 0
+	err := retry.Do(ctx, retry.Options{
+		Attempts:    maxAttempts,
+		BaseDelay:   retryDelay,
+		MaxDelay:    retryDelay,
+		Retryable:   s.retryClassifier,
+		AdjustDelay: retryDelayFor,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			s.recordFailure(config, operation)
+			s.logger.Warn("Operation failed, retrying",
+				gozap.String("operation", operation),
+				gozap.Int("attempt", attempt),
+				gozap.Int("maxAttempts", maxAttempts),
+				gozap.Duration("retryDelay", delay),
+				gozap.Error(err))
+		},
+	}, func() error {
+		attempts++
+		return fn()
+	})
+
+	switch {
+	case err == nil:
+		s.recordSuccess(config, operation)
+		if attempts > 1 {
 			s.logger.Info("Operation succeeded after retry",
 				gozap.String("operation", operation),
-				gozap.Int("attempt", attempt))
+				gozap.Int("attempt", attempts))
 		}
 		return nil
+	case ctx.Err() != nil:
+		// Out of time rather than a failing dependency, so it doesn't count
+		// towards the circuit breaker
+		if err == ctx.Err() {
+			return fmt.Errorf("%s: %w", operation, err)
+		}
+		return err
+	case !s.retryClassifier(err):
+		s.logger.Info("Operation failed with non-retryable error",
+			gozap.String("operation", operation),
+			gozap.Int("attempt", attempts),
+			gozap.Error(err))
+		return err
+	default:
+		s.recordFailure(config, operation)
+		s.logger.Error(err, "Operation failed after all retry attempts",
+			gozap.String("operation", operation),
+			gozap.Int("attempts", attempts))
+		return err
 	}
-	return lastErr
 }
 
-func (s *Service) findEcp(snapshot *konflux.Snapshot) (string, error) {
-	ctx := context.Background()
-	return konflux.FindEnterpriseContractPolicy(ctx, s.crtlClient, s.logger, snapshot)
+// retryingClientReader wraps the controller-runtime client so that the
+// konflux lookups get the same retry and circuit breaker treatment as the
+// other API calls
+type retryingClientReader struct {
+	service   *Service
+	config    *TaskRunConfig
+	operation string
 }
 
-func (s *Service) createTaskRun(snapshot *konflux.Snapshot, config *TaskRunConfig, taskNamespace string) (*tektonv1.TaskRun, error) {
-	// Validate required fields
-	if config.TaskName == "" {
-		return nil, fmt.Errorf("TASK_NAME is required but not set in configmap")
-	}
+func (r *retryingClientReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return r.service.retryK8sWithBackoff(ctx, r.config, r.operation, func() error {
+		return r.service.crtlClient.Get(ctx, key, obj, opts...)
+	})
+}
 
-	// Use the raw JSON spec directly
-	specJSON := snapshot.Spec
+func (r *retryingClientReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return r.service.retryK8sWithBackoff(ctx, r.config, r.operation, func() error {
+		return r.service.crtlClient.List(ctx, list, opts...)
+	})
+}
 
-	// Extract the primary image from the snapshot spec
-	var snapshotSpec struct {
-		Components []struct {
-			ContainerImage string `json:"containerImage"`
-		} `json:"components"`
+func (s *Service) findEcp(ctx context.Context, snapshot *konflux.Snapshot, config *TaskRunConfig) (konflux.ResolvedPolicy, error) {
+	opts, err := releasePlanLookupOptions(config)
+	if err != nil {
+		return konflux.ResolvedPolicy{}, err
+	}
+	if config.MatchReleasePlanByTarget == "true" {
+		// Snapshots without a target fall back to matching on application alone
+		opts.Target = snapshot.Target()
+		if opts.Target == "" {
+			s.logger.Warn("MATCH_RELEASEPLAN_BY_TARGET is set but the snapshot has no target label",
+				gozap.String("snapshot", snapshot.Name), gozap.String("label", konflux.SnapshotTargetLabel))
+		}
 	}
-	if err := json.Unmarshal(specJSON, &snapshotSpec); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal snapshot spec to extract components: %w", err)
+	if prefetched, ok := prefetchedEcpFrom(ctx); ok && prefetched.opts.Equal(opts) {
+		s.logger.Info("Using the policy looked up while reading the config", gozap.String("snapshot", snapshot.Name))
+		return prefetched.policy, prefetched.err
 	}
+	cli := &retryingClientReader{service: s, config: config, operation: "find-ecp"}
+	return konflux.ResolveEnterpriseContractPolicy(ctx, cli, s.logger, snapshot, opts)
+}
 
-	// log the specJSON
-	s.logger.Info("SpecJSON", gozap.String("specJSON", string(specJSON)))
-	// Helper function to create ParamValue with validation
-	createParamValue := func(value string) tektonv1.ParamValue {
-		if value == "" {
-			value = "true" // Default to "true" for boolean-like empty values
-		}
-		return tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: value}
+// DRY_RUN_MODE values. With log nothing is created, the TaskRun a snapshot
+// would get is only logged. With mark the TaskRun is created as usual but
+// carries dryRunLabel, so test runs can be told apart from real ones.
+const (
+	dryRunLog  = "log"
+	dryRunMark = "mark"
+)
+
+// dryRunMode returns the DRY_RUN_MODE set in the config, empty when dry runs
+// are off
+func dryRunMode(config *TaskRunConfig) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(config.DryRunMode)); mode {
+	case "", dryRunLog, dryRunMark:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("DRY_RUN_MODE %q is not supported, must be log or mark", config.DryRunMode)
 	}
+}
 
-	// Helper for numeric parameters with specific defaults
-	createNumericParamValue := func(value, defaultValue string) tektonv1.ParamValue {
-		if value == "" {
-			value = defaultValue
+// MISSING_APPLICATION_MODE values. Skipping acknowledges the event, while an
+// error gets it redelivered and eventually dead lettered, which can be
+// alerted on.
+const (
+	missingApplicationSkip  = "skip"
+	missingApplicationError = "error"
+)
+
+// missingApplicationMode returns the MISSING_APPLICATION_MODE set in the
+// config, defaulting to skip
+func missingApplicationMode(config *TaskRunConfig) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(config.MissingApplicationMode)); mode {
+	case "", missingApplicationSkip:
+		return missingApplicationSkip, nil
+	case missingApplicationError:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("MISSING_APPLICATION_MODE %q is not supported, must be skip or error", config.MissingApplicationMode)
+	}
+}
+
+// releasePlanLookupOptions returns the ReleasePlan lookup options set in the
+// config, rejecting an unknown RELEASEPLAN_AMBIGUITY_MODE or an invalid
+// namespace in RELEASEPLAN_SEARCH_NAMESPACES
+func releasePlanLookupOptions(config *TaskRunConfig) (konflux.LookupOptions, error) {
+	var opts konflux.LookupOptions
+	switch mode := konflux.AmbiguityMode(strings.ToLower(strings.TrimSpace(config.ReleasePlanAmbiguityMode))); mode {
+	case "", konflux.AmbiguityFirst:
+		opts.AmbiguityMode = konflux.AmbiguityFirst
+	case konflux.AmbiguityError:
+		opts.AmbiguityMode = mode
+	default:
+		return opts, fmt.Errorf("RELEASEPLAN_AMBIGUITY_MODE %q is not supported, must be first or error", config.ReleasePlanAmbiguityMode)
+	}
+	for _, namespace := range strings.Split(config.ReleasePlanSearchNamespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace == "" {
+			continue
 		}
-		return tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: value}
+		if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+			return opts, fmt.Errorf("invalid namespace %q in RELEASEPLAN_SEARCH_NAMESPACES: %s", namespace, strings.Join(msgs, "; "))
+		}
+		opts.SearchNamespaces = append(opts.SearchNamespaces, namespace)
 	}
+	return opts, nil
+}
 
-	ecp, err := s.findEcp(snapshot)
+// findSecretValue reads a key from a secret, serving it from the secret cache
+// when it was read recently. A failed read drops the cached value, including
+// one a concurrent lookup just stored, so a secret that's been removed isn't
+// served stale.
+func (s *Service) findSecretValue(ctx context.Context, config *TaskRunConfig, namespace, name, key string) (string, error) {
+	cacheKey := secretValueCacheKey(namespace, name, key)
+	if value, found := s.secretCache.get(cacheKey); found {
+		return value, nil
+	}
+	cli := &retryingClientReader{service: s, config: config, operation: "read-secret"}
+	value, err := konflux.FindSecretValue(ctx, cli, namespace, name, key)
 	if err != nil {
-		// If the findEcp lookup fails it generally means there was no ReleasePlan
-		// or no ReleasePlanAdmission found for the Snapshot's Application. In that
-		// situation we expect that the Snapshot is not likely to be released.
-		//
-		// This might change in future, but initially, the release pipeline is the
-		// only place where VSAs are considered, so if we think the Snapshot won't
-		// be released, then let's not bother creating a VSA.
-		//
-		// No TaskRun was created, but we don't consider it an error. Return a nil
-		// TaskRun and expect the caller to notice.
-		s.logger.Info("Unable to find RPA in cluster. Skipping VSA creation.", gozap.Error(err))
-		return nil, nil
-	} else {
-		s.logger.Info("Found RPA in cluster. Using correct ECP.")
+		s.secretCache.remove(cacheKey)
+		return "", err
+	}
+	s.secretCache.set(cacheKey, value)
+	return value, nil
+}
+
+// resolvePublicKey returns the public key to verify images with. A public key
+// secret set in the RPA takes precedence, falling back to PUBLIC_KEY if there
+// isn't one or it can't be read.
+func (s *Service) resolvePublicKey(ctx context.Context, policy konflux.ResolvedPolicy, config *TaskRunConfig) string {
+	ref := policy.PublicKeySecret
+	if ref == nil {
+		return config.PublicKey
 	}
+	publicKey, err := s.findSecretValue(ctx, config, ref.Namespace, ref.Name, ref.Key)
+	if err != nil {
+		s.logger.Warn("Unable to read public key from RPA secret, falling back to PUBLIC_KEY",
+			gozap.String("secret", ref.Namespace+"/"+ref.Name),
+			gozap.Error(err))
+		return config.PublicKey
+	}
+	s.logger.Info("Using public key from RPA secret",
+		gozap.String("secret", ref.Namespace+"/"+ref.Name),
+		gozap.String("key", ref.Key))
+	return publicKey
+}
 
+// signingKeySecretName returns the secret holding the VSA signing key, which
+// is mounted into the TaskRun. A secret set in the RPA takes precedence over
+// VSA_SIGNING_KEY_SECRET_NAME.
+func (s *Service) signingKeySecretName(policy konflux.ResolvedPolicy, config *TaskRunConfig) string {
+	if policy.SigningKeySecretName != "" {
+		s.logger.Info("Using VSA signing key from RPA secret", gozap.String("secret", policy.SigningKeySecretName))
+		return policy.SigningKeySecretName
+	}
 	s.logger.Info("Using VSA signing key from mounted secret.")
+	return config.VsaSigningKeySecretName
+}
+
+// defaultVsaUploadUrlSecretKey is the secret key holding the upload URL when
+// VSA_UPLOAD_URL_SECRET_KEY isn't set
+const defaultVsaUploadUrlSecretKey = "url"
+
+// resolveVsaUploadUrl returns the VSA upload URL. When VSA_UPLOAD_URL_SECRET_NAME
+// is set the URL is read from that secret in the given namespace, otherwise (or
+// if the secret can't be read) the plaintext VSA_UPLOAD_URL is used.
+func (s *Service) resolveVsaUploadUrl(ctx context.Context, config *TaskRunConfig, namespace string) (string, error) {
+	if config.VsaUploadUrlSecretName != "" {
+		secretKey := config.VsaUploadUrlSecretKey
+		if secretKey == "" {
+			secretKey = defaultVsaUploadUrlSecretKey
+		}
+		url, err := s.findSecretValue(ctx, config, namespace, config.VsaUploadUrlSecretName, secretKey)
+		if err == nil {
+			s.logger.Info("Using VSA upload URL from secret",
+				gozap.String("secret", config.VsaUploadUrlSecretName),
+				gozap.String("key", secretKey))
+			return url, nil
+		}
+		if config.VsaUploadUrl == "" {
+			return "", fmt.Errorf("failed to read VSA upload URL from secret: %w", err)
+		}
+		s.logger.Warn("Unable to read VSA upload URL from secret, falling back to VSA_UPLOAD_URL", gozap.Error(err))
+	}
 
-	// Validate VSA upload URL is configured
 	if config.VsaUploadUrl == "" {
-		return nil, fmt.Errorf("VSA upload URL is not set")
+		return "", fmt.Errorf("VSA upload URL is not set")
 	}
+	return config.VsaUploadUrl, nil
+}
 
-	params := []tektonv1.Param{
-		{Name: "IMAGES", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: string(specJSON)}},
-		{Name: "POLICY_CONFIGURATION", Value: createParamValue(ecp)},
-		{Name: "PUBLIC_KEY", Value: createParamValue(config.PublicKey)},
-		{Name: "VSA_UPLOAD_URL", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: config.VsaUploadUrl}},
-		{Name: "IGNORE_REKOR", Value: createParamValue(config.IgnoreRekor)},
-		{Name: "STRICT", Value: createParamValue(config.Strict)},
-		{Name: "WORKERS", Value: createNumericParamValue(config.Workers, "1")},
-		{Name: "DEBUG", Value: createParamValue(config.Debug)},
+// defaultAllowedUploadSchemes are the VSA upload URL schemes accepted when
+// ALLOWED_UPLOAD_SCHEMES isn't set
+var defaultAllowedUploadSchemes = []string{"oci", "https"}
+
+// validateVsaUploadUrl checks the VSA upload URL parses and uses one of the
+// allowed schemes, returning the normalized URL. The URL may be prefixed with
+// an upload backend, e.g. "rekor@https://rekor.sigstore.dev", in which case
+// the scheme of the part after the "@" is checked. The URL itself is kept out
+// of error messages since it may carry credentials.
+func validateVsaUploadUrl(rawUrl string, allowedSchemes string) (string, error) {
+	normalized := strings.TrimSpace(rawUrl)
+
+	location := normalized
+	if idx := strings.Index(normalized, "@"); idx > 0 && !strings.ContainsAny(normalized[:idx], ":/") {
+		location = normalized[idx+1:]
 	}
 
-	// Debug logging for all parameters
-	for _, param := range params {
-		s.logger.Info("TaskRun param", gozap.String("name", param.Name), gozap.String("type", string(param.Value.Type)), gozap.String("value", param.Value.StringVal))
+	parsed, err := url.Parse(location)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("invalid VSA upload URL: %w", err)
 	}
 
-	return &tektonv1.TaskRun{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("verify-conforma-%s-%d", snapshot.Name, time.Now().Unix()),
-			Namespace: taskNamespace,
-			Labels: map[string]string{
-				"app.kubernetes.io/name":       "verify-and-create-vsa",
-				"app.kubernetes.io/instance":   snapshot.Name,
-				"app.kubernetes.io/component":  "conforma",
-				"app.kubernetes.io/part-of":    "konflux",
-				"app.kubernetes.io/managed-by": "conforma-knative-service",
-			},
-		},
-		Spec: tektonv1.TaskRunSpec{
-			TaskRef: &tektonv1.TaskRef{
-				ResolverRef: tektonv1.ResolverRef{
-					Resolver: "cluster",
-					Params: tektonv1.Params{
-						{Name: "kind", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: "task"}},
-						{Name: "name", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: config.TaskName}},
-						{Name: "namespace", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: taskNamespace}},
-					},
-				},
+	allowed := defaultAllowedUploadSchemes
+	if allowedSchemes != "" {
+		allowed = nil
+		for _, scheme := range strings.Split(allowedSchemes, ",") {
+			if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+				allowed = append(allowed, scheme)
+			}
+		}
+	}
+
+	if parsed.Scheme == "" {
+		return "", fmt.Errorf("invalid VSA upload URL: missing scheme, expected one of: %s", strings.Join(allowed, ", "))
+	}
+	if !slices.Contains(allowed, parsed.Scheme) {
+		return "", fmt.Errorf("invalid VSA upload URL: scheme %q is not allowed, expected one of: %s", parsed.Scheme, strings.Join(allowed, ", "))
+	}
+	return normalized, nil
+}
+
+// checkImageRegistries checks every component's image is hosted on one of
+// the registries in ALLOWED_IMAGE_REGISTRIES, a comma separated list of
+// registry hosts such as "quay.io,registry.redhat.io". Images without a
+// registry are on docker.io. An empty list allows every registry.
+func checkImageRegistries(spec *konflux.SnapshotSpec, allowedRegistries string) error {
+	var allowed []string
+	for _, registry := range strings.Split(allowedRegistries, ",") {
+		if registry = strings.ToLower(strings.TrimSpace(registry)); registry != "" {
+			allowed = append(allowed, registry)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	for _, component := range spec.Components {
+		named, err := reference.ParseNormalizedNamed(component.ContainerImage)
+		if err != nil {
+			return fmt.Errorf("component %q has an invalid image reference %q: %w", component.Name, component.ContainerImage, err)
+		}
+		registry := strings.ToLower(reference.Domain(named))
+		if !slices.Contains(allowed, registry) {
+			return fmt.Errorf("component %q image registry %q is not allowed, expected one of: %s", component.Name, registry, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// TaskRunNameGenerator returns the name of the TaskRun to create for a
+// snapshot, given the TASKRUN_NAME_PREFIX in effect
+type TaskRunNameGenerator func(prefix string, snapshot *konflux.Snapshot) string
+
+// defaultTaskRunName names the TaskRun after the snapshot and the current
+// time in seconds
+func defaultTaskRunName(prefix string, snapshot *konflux.Snapshot) string {
+	return fmt.Sprintf("%s%s-%d", prefix, snapshot.Name, time.Now().Unix())
+}
+
+// defaultTaskRunNamePrefix is the TaskRun name prefix used when
+// TASKRUN_NAME_PREFIX isn't set
+const defaultTaskRunNamePrefix = "verify-conforma-"
+
+// taskRunNamePrefix returns TASKRUN_NAME_PREFIX, or defaultTaskRunNamePrefix
+// if it isn't set
+func taskRunNamePrefix(config *TaskRunConfig) string {
+	if config.TaskRunNamePrefix == "" {
+		return defaultTaskRunNamePrefix
+	}
+	return config.TaskRunNamePrefix
+}
+
+// Labels identifying the TaskRuns created by this service
+const (
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "conforma-knative-service"
+	instanceLabel  = "app.kubernetes.io/instance"
+	// The resourceVersion of the snapshot the TaskRun verified
+	snapshotVersionLabel = "conforma.dev/snapshot-resource-version"
+	// Set to "true" on TaskRuns created with DRY_RUN_MODE=mark
+	dryRunLabel = "conforma.dev/dry-run"
+)
+
+// applicationPolicyOverride looks up the application in the
+// APPLICATION_POLICY_OVERRIDES config, a JSON object mapping application
+// names to "namespace/policy" references
+func applicationPolicyOverride(config *TaskRunConfig, appName string) (string, bool, error) {
+	if config.ApplicationPolicyOverrides == "" || appName == "" {
+		return "", false, nil
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal([]byte(config.ApplicationPolicyOverrides), &overrides); err != nil {
+		return "", false, fmt.Errorf("failed to parse APPLICATION_POLICY_OVERRIDES: %w", err)
+	}
+	policy, found := overrides[appName]
+	if !found || policy == "" {
+		return "", false, nil
+	}
+	return policy, true, nil
+}
+
+// defaultMissingReleasePlanGraceSeconds is how long a snapshot keeps being
+// redelivered while its ReleasePlan is missing
+const defaultMissingReleasePlanGraceSeconds = 300
+
+// retryMissingReleasePlan reports whether a snapshot without a ReleasePlan
+// should be redelivered instead of skipped. With RETRY_ON_MISSING_RELEASEPLAN
+// set this is true until the grace window since the ReleasePlan was first
+// found missing has passed.
+func (s *Service) retryMissingReleasePlan(snapshot *konflux.Snapshot, config *TaskRunConfig) bool {
+	if config.RetryOnMissingReleasePlan != "true" {
+		return false
+	}
+
+	graceSeconds := defaultMissingReleasePlanGraceSeconds
+	if config.MissingReleasePlanGraceSeconds != "" {
+		if parsed, err := strconv.Atoi(config.MissingReleasePlanGraceSeconds); err == nil && parsed > 0 {
+			graceSeconds = parsed
+		}
+	}
+	grace := time.Duration(graceSeconds) * time.Second
+
+	key := snapshot.Namespace + "/" + snapshot.Name
+	now := time.Now()
+
+	s.missingReleasePlanMu.Lock()
+	defer s.missingReleasePlanMu.Unlock()
+
+	// Drop snapshots that were never redelivered so the map stays small
+	for k, firstSeen := range s.missingReleasePlans {
+		if k != key && now.Sub(firstSeen) > grace {
+			delete(s.missingReleasePlans, k)
+		}
+	}
+
+	firstSeen, found := s.missingReleasePlans[key]
+	if !found {
+		s.missingReleasePlans[key] = now
+		return true
+	}
+	if now.Sub(firstSeen) < grace {
+		return true
+	}
+	delete(s.missingReleasePlans, key)
+	return false
+}
+
+// defaultTaskKind is the resolver kind used when TASK_KIND isn't set
+const defaultTaskKind = "task"
+
+// allowedTaskKinds are the resolver kinds TASK_KIND may be set to
+var allowedTaskKinds = map[string]bool{
+	"task":        true,
+	"clustertask": true,
+}
+
+// taskKind returns the resolver kind for the Task, rejecting kinds outside
+// allowedTaskKinds
+func taskKind(config *TaskRunConfig) (string, error) {
+	kind := strings.ToLower(strings.TrimSpace(config.TaskKind))
+	if kind == "" {
+		return defaultTaskKind, nil
+	}
+	if !allowedTaskKinds[kind] {
+		return "", fmt.Errorf("TASK_KIND %q is not supported", config.TaskKind)
+	}
+	return kind, nil
+}
+
+// Volume types SCRATCH_WORKSPACE_TYPE may be set to
+const (
+	scratchWorkspaceEmptyDir = "emptydir"
+	scratchWorkspacePVC      = "pvc"
+)
+
+// signingKeyWorkspace is the workspace the VSA signing key is mounted in
+const signingKeyWorkspace = "signing-key"
+
+// scratchWorkspace returns the optional scratch workspace binding configured
+// with SCRATCH_WORKSPACE_NAME, or nil when it isn't set. The workspace is an
+// emptyDir unless SCRATCH_WORKSPACE_TYPE is "pvc", in which case
+// SCRATCH_WORKSPACE_CLAIM_NAME names the claim to bind.
+func scratchWorkspace(config *TaskRunConfig) (*tektonv1.WorkspaceBinding, error) {
+	name := strings.TrimSpace(config.ScratchWorkspaceName)
+	if name == "" {
+		return nil, nil
+	}
+	if name == signingKeyWorkspace {
+		return nil, fmt.Errorf("SCRATCH_WORKSPACE_NAME %q is already used for the signing key", name)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(config.ScratchWorkspaceType)) {
+	case "", scratchWorkspaceEmptyDir:
+		return &tektonv1.WorkspaceBinding{Name: name, EmptyDir: &corev1.EmptyDirVolumeSource{}}, nil
+	case scratchWorkspacePVC:
+		claimName := strings.TrimSpace(config.ScratchWorkspaceClaimName)
+		if claimName == "" {
+			return nil, fmt.Errorf("SCRATCH_WORKSPACE_CLAIM_NAME is required when SCRATCH_WORKSPACE_TYPE is pvc")
+		}
+		return &tektonv1.WorkspaceBinding{
+			Name:                  name,
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		}, nil
+	default:
+		return nil, fmt.Errorf("SCRATCH_WORKSPACE_TYPE %q is not supported, must be emptyDir or pvc", config.ScratchWorkspaceType)
+	}
+}
+
+// restrictedPodSecurityContext is the pod security context required by the
+// restricted Pod Security Standard. A TaskRun can't set the security context
+// of the step containers, Tekton sets the restricted one on them, dropping
+// all capabilities and disallowing privilege escalation, when its
+// set-security-context feature flag is enabled.
+func restrictedPodSecurityContext() *corev1.PodSecurityContext {
+	runAsNonRoot := true
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// parseTaskRunEnv parses TASKRUN_ENV, a comma-separated list of NAME=value
+// pairs, into env vars for the TaskRun's containers
+func parseTaskRunEnv(raw string) ([]corev1.EnvVar, error) {
+	var env []corev1.EnvVar
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid TASKRUN_ENV entry %q: expected NAME=value", entry)
+		}
+		name = strings.TrimSpace(name)
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid TASKRUN_ENV name %q: %s", name, strings.Join(errs, "; "))
+		}
+		env = append(env, corev1.EnvVar{Name: name, Value: value})
+	}
+	return env, nil
+}
+
+// managedTaskRunParams are the TaskRun params set by createTaskRun, which
+// TASKRUN_EXTRA_PARAMS can't override
+var managedTaskRunParams = []string{
+	"IMAGES",
+	"POLICY_CONFIGURATION",
+	"PUBLIC_KEY",
+	"VSA_UPLOAD_URL",
+	"IGNORE_REKOR",
+	"STRICT",
+	"WORKERS",
+	"DEBUG",
+}
+
+// taskRunParamNamePattern is what Tekton accepts as a param name
+var taskRunParamNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+// parseTaskRunExtraParams parses TASKRUN_EXTRA_PARAMS, a JSON object of param
+// names to string values, into params added to the TaskRun. It lets new Task
+// params be set without a change to the service. The params are sorted by
+// name so the TaskRun doesn't change from one snapshot to the next.
+func parseTaskRunExtraParams(raw string) ([]tektonv1.Param, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse TASKRUN_EXTRA_PARAMS: %w", err)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if !taskRunParamNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid TASKRUN_EXTRA_PARAMS name %q: must start with a letter or underscore and contain only letters, digits, '_', '-' and '.'", name)
+		}
+		if slices.Contains(managedTaskRunParams, name) {
+			return nil, fmt.Errorf("TASKRUN_EXTRA_PARAMS can't set %s, it's set by the service", name)
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	params := make([]tektonv1.Param, 0, len(names))
+	for _, name := range names {
+		params = append(params, tektonv1.Param{Name: name, Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: values[name]}})
+	}
+	return params, nil
+}
+
+// maxPropagatedAnnotationBytes bounds the size of a single Snapshot
+// annotation copied to the TaskRun, so that something like
+// kubectl.kubernetes.io/last-applied-configuration can't bloat every TaskRun
+const maxPropagatedAnnotationBytes = 4096
+
+// Annotations recording the CloudEvent a TaskRun was created for
+const (
+	eventIDAnnotation     = "conforma.dev/ce-id"
+	eventSourceAnnotation = "conforma.dev/ce-source"
+)
+
+// maxEventOriginValueBytes bounds the event id and source copied to the
+// TaskRun, as both are set by the sender
+const maxEventOriginValueBytes = 512
+
+// eventOrigin identifies the CloudEvent being processed
+type eventOrigin struct {
+	id     string
+	source string
+}
+
+type eventOriginKey struct{}
+
+// withEventOrigin records the event's id and source in the context
+func withEventOrigin(ctx context.Context, event cloudevents.Event) context.Context {
+	return context.WithValue(ctx, eventOriginKey{}, eventOrigin{id: event.ID(), source: event.Source()})
+}
+
+// eventOriginFrom returns the event recorded by withEventOrigin, if any
+func eventOriginFrom(ctx context.Context) (eventOrigin, bool) {
+	origin, ok := ctx.Value(eventOriginKey{}).(eventOrigin)
+	return origin, ok
+}
+
+// stampEventOrigin annotates the TaskRun with the event it was created for
+func stampEventOrigin(taskRun *tektonv1.TaskRun, origin eventOrigin) {
+	for key, value := range map[string]string{
+		eventIDAnnotation:     sanitizeAnnotationValue(origin.id),
+		eventSourceAnnotation: sanitizeAnnotationValue(origin.source),
+	} {
+		if value == "" {
+			continue
+		}
+		if taskRun.Annotations == nil {
+			taskRun.Annotations = map[string]string{}
+		}
+		taskRun.Annotations[key] = value
+	}
+}
+
+// traceparentAnnotation holds the W3C traceparent of the span the TaskRun was
+// created in, so the task's own spans can be linked to it
+const traceparentAnnotation = "conforma.dev/traceparent"
+
+// stampTraceparent annotates the TaskRun with the span active in the context.
+// Without a valid span, e.g. when tracing isn't enabled, nothing is added.
+func stampTraceparent(ctx context.Context, taskRun *tektonv1.TaskRun) {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	traceparent := carrier.Get("traceparent")
+	if traceparent == "" {
+		return
+	}
+	if taskRun.Annotations == nil {
+		taskRun.Annotations = map[string]string{}
+	}
+	taskRun.Annotations[traceparentAnnotation] = traceparent
+}
+
+// sanitizeAnnotationValue drops non-printable characters and truncates the
+// value to maxEventOriginValueBytes, keeping it valid UTF-8
+func sanitizeAnnotationValue(val string) string {
+	val = strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(val, ""))
+	if len(val) > maxEventOriginValueBytes {
+		val = strings.ToValidUTF8(val[:maxEventOriginValueBytes], "")
+	}
+	return strings.TrimSpace(val)
+}
+
+// taskRunAnnotations returns the annotations to set on the TaskRun: Snapshot
+// annotations matching PROPAGATE_ANNOTATION_PREFIXES, and the log streaming
+// annotation. Tekton copies TaskRun annotations to the pod, which is where a
+// log streaming sidecar looks for LOG_STREAMING_ANNOTATION_KEY.
+func (s *Service) taskRunAnnotations(snapshot *konflux.Snapshot, config *TaskRunConfig) (map[string]string, error) {
+	annotations := map[string]string{}
+
+	var prefixes []string
+	for _, prefix := range strings.Split(config.PropagateAnnotationPrefixes, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	for key, value := range snapshot.Annotations {
+		if !slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+			continue
+		}
+		if size := len(key) + len(value); size > maxPropagatedAnnotationBytes {
+			s.logger.Warn("Not propagating oversized snapshot annotation",
+				gozap.String("key", key),
+				gozap.Int("size", size),
+				gozap.Int("limit", maxPropagatedAnnotationBytes))
+			continue
+		}
+		annotations[key] = value
+	}
+
+	if config.LogStreamingAnnotationKey != "" {
+		if errs := validation.IsQualifiedName(config.LogStreamingAnnotationKey); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid LOG_STREAMING_ANNOTATION_KEY %q: %s", config.LogStreamingAnnotationKey, strings.Join(errs, "; "))
+		}
+		annotations[config.LogStreamingAnnotationKey] = config.LogStreamingAnnotationValue
+	}
+
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+	return annotations, nil
+}
+
+// componentSourcesAnnotation holds the git source of each snapshot component
+// that has one, so the task's results can be correlated with the commits
+// they were built from
+const componentSourcesAnnotation = "conforma.dev/component-sources"
+
+// maxComponentSourcesAnnotationBytes bounds the component sources annotation,
+// keeping snapshots with many components well under the 256KiB limit on an
+// object's annotations
+const maxComponentSourcesAnnotationBytes = 32 * 1024
+
+// componentSourcesAnnotationValue returns the JSON object mapping component
+// names to their git source, or "" if no component has one or the result is
+// too large
+func (s *Service) componentSourcesAnnotationValue(snapshot *konflux.Snapshot, spec *konflux.SnapshotSpec) string {
+	sources := map[string]*konflux.GitSource{}
+	for _, component := range spec.Components {
+		if component.Source == nil || component.Source.Git == nil || component.Source.Git.URL == "" {
+			continue
+		}
+		sources[component.Name] = component.Source.Git
+	}
+	if len(sources) == 0 {
+		return ""
+	}
+
+	value, err := json.Marshal(sources)
+	if err != nil {
+		s.logger.Warn("Failed to encode component sources", gozap.String("snapshot", snapshot.Name), gozap.Error(err))
+		return ""
+	}
+	if len(value) > maxComponentSourcesAnnotationBytes {
+		s.logger.Warn("Not annotating TaskRun with oversized component sources",
+			gozap.String("snapshot", snapshot.Name),
+			gozap.Int("size", len(value)),
+			gozap.Int("limit", maxComponentSourcesAnnotationBytes))
+		return ""
+	}
+	return string(value)
+}
+
+// Annotations holding the snapshot's display name and description
+const (
+	snapshotDisplayNameAnnotation        = "conforma.dev/snapshot-display-name"
+	snapshotDisplayDescriptionAnnotation = "conforma.dev/snapshot-display-description"
+)
+
+// maxSnapshotDisplayValueBytes bounds the display name and description
+// copied to the TaskRun, longer values are truncated
+const maxSnapshotDisplayValueBytes = maxPropagatedAnnotationBytes
+
+// snapshotDisplayAnnotations returns the annotations holding the snapshot's
+// display name and description, skipping those the snapshot doesn't have
+func snapshotDisplayAnnotations(spec *konflux.SnapshotSpec) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range map[string]string{
+		snapshotDisplayNameAnnotation:        spec.DisplayName,
+		snapshotDisplayDescriptionAnnotation: spec.DisplayDescription,
+	} {
+		if len(value) > maxSnapshotDisplayValueBytes {
+			value = strings.ToValidUTF8(value[:maxSnapshotDisplayValueBytes], "")
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// validateTaskRunConfig checks the config map settings needed to create a
+// TaskRun. Every problem found is reported, joined into one error, so a
+// misconfigured config map can be fixed in one pass.
+func validateTaskRunConfig(config *TaskRunConfig) error {
+	var errs []error
+	if config.TaskName == "" {
+		errs = append(errs, fmt.Errorf("TASK_NAME is required but not set in configmap"))
+	}
+	if _, err := taskKind(config); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := releasePlanLookupOptions(config); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := missingApplicationMode(config); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := dryRunMode(config); err != nil {
+		errs = append(errs, err)
+	}
+	if config.LogStreamingAnnotationKey != "" {
+		if msgs := validation.IsQualifiedName(config.LogStreamingAnnotationKey); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid LOG_STREAMING_ANNOTATION_KEY %q: %s", config.LogStreamingAnnotationKey, strings.Join(msgs, "; ")))
+		}
+	}
+	if config.PipelineRunLabelKey != "" {
+		if msgs := validation.IsQualifiedName(config.PipelineRunLabelKey); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid PIPELINERUN_LABEL_KEY %q: %s", config.PipelineRunLabelKey, strings.Join(msgs, "; ")))
+		}
+	}
+	if _, err := parsePolicyResolutionOrder(config.PolicyResolutionOrder); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := parseTaskRunEnv(config.TaskRunEnv); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := parseTaskRunExtraParams(config.TaskRunExtraParams); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := scratchWorkspace(config); err != nil {
+		errs = append(errs, err)
+	}
+	if config.TaskRunPriorityClass != "" {
+		if msgs := validation.IsDNS1123Subdomain(config.TaskRunPriorityClass); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid TASKRUN_PRIORITY_CLASS %q: %s", config.TaskRunPriorityClass, strings.Join(msgs, "; ")))
+		}
+	}
+	if config.TaskRunNamePrefix != "" {
+		// As with generateName, the prefix is valid if a name made by
+		// adding a character to it is. The length limit is Tekton's.
+		if msgs := validation.IsDNS1123Label(config.TaskRunNamePrefix + "a"); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("invalid TASKRUN_NAME_PREFIX %q: %s", config.TaskRunNamePrefix, strings.Join(msgs, "; ")))
+		}
+	}
+	if config.VsaUploadUrl == "" && config.VsaUploadUrlSecretName == "" {
+		errs = append(errs, fmt.Errorf("VSA upload URL is not set"))
+	}
+	return errors.Join(errs...)
+}
+
+// defaultMaxWorkers is the most workers a TaskRun may ask for when
+// MAX_WORKERS isn't set
+const defaultMaxWorkers = 64
+
+// maxWorkers returns MAX_WORKERS, or defaultMaxWorkers if it isn't set
+func maxWorkers(config *TaskRunConfig) int {
+	if parsed, err := strconv.Atoi(config.MaxWorkers); err == nil && parsed > 0 {
+		return parsed
+	}
+	return defaultMaxWorkers
+}
+
+// taskRunWorkers returns the WORKERS param: WORKERS, falling back to
+// DEFAULT_WORKERS and then 1, clamped to between 1 and maxWorkers
+func (s *Service) taskRunWorkers(config *TaskRunConfig) string {
+	key, workers := "", 1
+	for _, setting := range []struct{ key, val string }{
+		{"WORKERS", config.Workers},
+		{"DEFAULT_WORKERS", config.DefaultWorkers},
+	} {
+		if parsed, err := strconv.Atoi(setting.val); err == nil {
+			key, workers = setting.key, parsed
+			break
+		}
+	}
+	limit := maxWorkers(config)
+	if clamped := min(max(workers, 1), limit); clamped != workers {
+		s.logger.Warn("Clamping out of range workers count",
+			gozap.String("key", key),
+			gozap.Int("value", workers),
+			gozap.Int("clamped", clamped),
+			gozap.Int("max", limit))
+		workers = clamped
+	}
+	return strconv.Itoa(workers)
+}
+
+// splitJoinedErrors returns the errors joined by errors.Join, such as the
+// ones from validateTaskRunConfig. Any other error is returned on its own.
+func splitJoinedErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+func (s *Service) createTaskRun(ctx context.Context, snapshot *konflux.Snapshot, config *TaskRunConfig, taskNamespace string) (*tektonv1.TaskRun, error) {
+	if err := validateTaskRunConfig(config); err != nil {
+		return nil, err
+	}
+	// validateTaskRunConfig has checked these parse
+	kind, _ := taskKind(config)
+	env, _ := parseTaskRunEnv(config.TaskRunEnv)
+	extraParams, _ := parseTaskRunExtraParams(config.TaskRunExtraParams)
+	scratch, _ := scratchWorkspace(config)
+	annotations, err := s.taskRunAnnotations(snapshot, config)
+	if err != nil {
+		return nil, err
+	}
+	var podTemplate *pod.Template
+	restricted := config.ApplyRestrictedSecurityContext == "true"
+	if len(env) > 0 || config.TaskRunPriorityClass != "" || restricted {
+		podTemplate = &pod.Template{Env: env}
+		if config.TaskRunPriorityClass != "" {
+			podTemplate.PriorityClassName = &config.TaskRunPriorityClass
+		}
+		if restricted {
+			podTemplate.SecurityContext = restrictedPodSecurityContext()
+		}
+	}
+
+	// Use the raw JSON spec directly, apart from moving the images to the
+	// field the task reads
+	specJSON, err := konflux.NormalizeComponentImageField(snapshot.Spec, config.ComponentImageField)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := konflux.ParseSnapshotSpec(specJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageRegistries(spec, config.AllowedImageRegistries); err != nil {
+		return nil, err
+	}
+	if sources := s.componentSourcesAnnotationValue(snapshot, spec); sources != "" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[componentSourcesAnnotation] = sources
+	}
+	if config.AnnotateSnapshotDisplayInfo == "true" {
+		for key, value := range snapshotDisplayAnnotations(spec) {
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = value
+		}
+	}
+
+	// log the specJSON
+	s.logger.Info("SpecJSON", gozap.String("specJSON", string(specJSON)))
+	// Helper function to create ParamValue with validation
+	createParamValue := func(value string) tektonv1.ParamValue {
+		if value == "" {
+			value = "true" // Default to "true" for boolean-like empty values
+		}
+		return tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: value}
+	}
+
+	policy, source, err := s.resolvePolicy(ctx, snapshot, config)
+	if err != nil {
+		return nil, err
+	}
+	if source == "" {
+		// If no source has a policy it generally means there was no ReleasePlan
+		// or no ReleasePlanAdmission found for the Snapshot's Application. In that
+		// situation we expect that the Snapshot is not likely to be released.
+		//
+		// This might change in future, but initially, the release pipeline is the
+		// only place where VSAs are considered, so if we think the Snapshot won't
+		// be released, then let's not bother creating a VSA.
+		//
+		// No TaskRun was created, but we don't consider it an error. Return a nil
+		// TaskRun and expect the caller to notice.
+		s.logger.Info("Unable to find RPA in cluster. Skipping VSA creation.")
+		return nil, nil
+	}
+	if config.VerifyPolicyExists == "true" {
+		if err := s.verifyPolicyExists(ctx, config, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	workspaces := []tektonv1.WorkspaceBinding{
+		{
+			Name: signingKeyWorkspace,
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: s.signingKeySecretName(policy, config),
 			},
-			Params:             params,
-			ServiceAccountName: "conforma-vsa-generator",
-			Workspaces: []tektonv1.WorkspaceBinding{
-				{
-					Name: "signing-key",
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: config.VsaSigningKeySecretName,
+		},
+	}
+	if scratch != nil {
+		workspaces = append(workspaces, *scratch)
+	}
+
+	publicKey := s.resolvePublicKey(ctx, policy, config)
+
+	// Validate VSA upload URL is configured
+	vsaUploadUrl, err := s.resolveVsaUploadUrl(ctx, config, taskNamespace)
+	if err != nil {
+		return nil, err
+	}
+	vsaUploadUrl, err = validateVsaUploadUrl(vsaUploadUrl, config.AllowedUploadSchemes)
+	if err != nil {
+		return nil, err
+	}
+
+	params := []tektonv1.Param{
+		{Name: "IMAGES", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: string(specJSON)}},
+		{Name: "POLICY_CONFIGURATION", Value: createParamValue(policy.String())},
+		{Name: "PUBLIC_KEY", Value: createParamValue(publicKey)},
+		{Name: "VSA_UPLOAD_URL", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: vsaUploadUrl}},
+		{Name: "IGNORE_REKOR", Value: createParamValue(config.IgnoreRekor)},
+		{Name: "STRICT", Value: createParamValue(config.Strict)},
+		{Name: "WORKERS", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: s.taskRunWorkers(config)}},
+		{Name: "DEBUG", Value: createParamValue(config.Debug)},
+	}
+	params = append(params, extraParams...)
+
+	// Debug logging for all parameters
+	for _, param := range params {
+		value := param.Value.StringVal
+		if param.Name == "VSA_UPLOAD_URL" && config.VsaUploadUrlSecretName != "" {
+			// The URL came from a secret, most likely because it has credentials in it
+			value = "<redacted>"
+		}
+		s.logger.Info("TaskRun param", gozap.String("name", param.Name), gozap.String("type", string(param.Value.Type)), gozap.String("value", value))
+	}
+
+	// The Task is resolved from the TaskRun namespace unless it's installed
+	// centrally
+	resolverNamespace := config.TaskNamespace
+	if resolverNamespace == "" {
+		resolverNamespace = taskNamespace
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/name":      "verify-and-create-vsa",
+		instanceLabel:                 snapshot.Name,
+		"app.kubernetes.io/component": "conforma",
+		"app.kubernetes.io/part-of":   "konflux",
+		managedByLabel:                managedByValue,
+	}
+	if version := snapshotVersionLabelValue(snapshot); version != "" {
+		labels[snapshotVersionLabel] = version
+	}
+	if mode, _ := dryRunMode(config); mode == dryRunMark {
+		labels[dryRunLabel] = "true"
+	}
+	if config.PipelineRunLabelKey != "" {
+		if pipelineRun, ok := snapshot.Labels[config.PipelineRunLabelKey]; ok {
+			labels[config.PipelineRunLabelKey] = pipelineRun
+		}
+	}
+
+	prefix := taskRunNamePrefix(config)
+	name := s.taskRunName(prefix, snapshot)
+	if config.ApplyTaskRuns == "true" {
+		name = prefix + snapshot.Name
+	}
+
+	return &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   taskNamespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: tektonv1.TaskRunSpec{
+			TaskRef: &tektonv1.TaskRef{
+				ResolverRef: tektonv1.ResolverRef{
+					Resolver: "cluster",
+					Params: tektonv1.Params{
+						{Name: "kind", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: kind}},
+						{Name: "name", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: config.TaskName}},
+						{Name: "namespace", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: resolverNamespace}},
 					},
 				},
 			},
+			Params:             params,
+			PodTemplate:        podTemplate,
+			ServiceAccountName: "conforma-vsa-generator",
+			Workspaces:         workspaces,
 		},
 	}, nil
 }
 
 // --- HTTP server ---
+
+// Retries of a receiver that fails to start, such as when the port is briefly
+// still held at startup. The delay doubles after each attempt up to the max.
+const (
+	defaultListenRetryDelay = time.Second
+	maxListenRetryDelay     = 30 * time.Second
+)
+
 type Server struct {
 	service  *Service
 	port     string
 	ceClient CloudEventsClient
+	// How many times to try starting the receiver, set from
+	// LISTEN_RETRY_ATTEMPTS
+	listenRetryAttempts int
+	listenRetryDelay    time.Duration
 }
 
 func NewServer(service *Service, port string, ceClient CloudEventsClient) *Server {
-	return &Server{service: service, port: port, ceClient: ceClient}
+	return &Server{
+		service:             service,
+		port:                port,
+		ceClient:            ceClient,
+		listenRetryAttempts: 1,
+		listenRetryDelay:    defaultListenRetryDelay,
+	}
 }
 
 func (s *Server) Start() error {
+	return s.Run(context.Background())
+}
+
+// Run serves events until the context is cancelled. If the receiver fails to
+// start it's retried with backoff up to listenRetryAttempts times.
+func (s *Server) Run(ctx context.Context) error {
 	s.service.logger.Info("Starting server", gozap.String("port", s.port))
-	return s.ceClient.StartReceiver(context.Background(), s.service.handleCloudEvent)
+	delay := s.listenRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.ceClient.StartReceiver(ctx, s.service.handleCloudEvent)
+		if err == nil || ctx.Err() != nil || attempt >= s.listenRetryAttempts {
+			return err
+		}
+		s.service.logger.Warn("Failed to start receiver, retrying",
+			gozap.Error(err),
+			gozap.Int("attempt", attempt),
+			gozap.Int("maxAttempts", s.listenRetryAttempts),
+			gozap.Duration("delay", delay))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxListenRetryDelay)
+	}
 }
 
-func main() {
-	service, err := NewService(ServiceConfig{})
+// defaultMaxEventBodyBytes bounds the size of an incoming event body when
+// MAX_EVENT_BODY_BYTES is not set. Kubernetes objects are capped at ~1.5MiB
+// by etcd, so this leaves plenty of headroom for the CloudEvent envelope.
+const defaultMaxEventBodyBytes int64 = 4 << 20
+
+// getEnvInt64 reads a positive integer from the environment, falling back to
+// the default when the variable is unset or invalid
+func getEnvInt64(name string, defaultValue int64) int64 {
+	if val := os.Getenv(name); val != "" {
+		if parsed, parseErr := strconv.ParseInt(val, 10, 64); parseErr == nil && parsed > 0 {
+			return parsed
+		}
+		log.Printf("Ignoring invalid value for %s: %q", name, val)
+	}
+	return defaultValue
+}
+
+// serviceConfigFromEnv reads the ServiceConfig settings that can be set with
+// environment variables. Anything unset is left to NewServiceWithDependencies
+// to default. The cache TTL comes from CACHE_TTL_MINUTES here rather than the
+// config map, since the TTL applies to the cache holding the config map.
+func serviceConfigFromEnv() ServiceConfig {
+	return ServiceConfig{
+		ConfigMapName:         os.Getenv("CONFIG_MAP_NAME"),
+		ConfigMapNames:        parseConfigMapNames(os.Getenv("CONFIG_MAP_NAMES")),
+		CacheTTL:              time.Duration(getEnvInt64("CACHE_TTL_MINUTES", 0)) * time.Minute,
+		CacheMaxEntries:       int(getEnvInt64("CACHE_MAX_ENTRIES", defaultCacheMaxEntries)),
+		CacheSweepInterval:    time.Duration(getEnvInt64("CACHE_SWEEP_INTERVAL_SECONDS", 0)) * time.Second,
+		CacheStatsInterval:    time.Duration(getEnvInt64("CACHE_STATS_LOG_INTERVAL_SECONDS", 0)) * time.Second,
+		SecretCacheTTL:        time.Duration(getEnvInt64("SECRET_CACHE_TTL_SECONDS", 0)) * time.Second,
+		ProcessTimeout:        time.Duration(getEnvInt64("PROCESS_TIMEOUT_SECONDS", 0)) * time.Second,
+		MaxRedeliveries:       int(getEnvInt64("MAX_REDELIVERIES", 0)),
+		LogSamplingInitial:    int(getEnvInt64("LOG_SAMPLING_INITIAL", 0)),
+		LogSamplingThereafter: int(getEnvInt64("LOG_SAMPLING_THEREAFTER", 0)),
+		SnapshotAPIVersion:    os.Getenv("SNAPSHOT_API_VERSION"),
+		SnapshotKind:          os.Getenv("SNAPSHOT_KIND"),
+	}
+}
+
+// parseConfigMapNames reads CONFIG_MAP_NAMES, a comma separated list of config
+// map names
+func parseConfigMapNames(val string) []string {
+	var names []string
+	for _, name := range strings.Split(val, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// apiServerAddEventType is the only CloudEvent type the service acts on
+const apiServerAddEventType = "dev.knative.apiserver.resource.add"
+
+// defaultConfigReadinessInterval is how often the config map is read at
+// startup until the service is ready
+const defaultConfigReadinessInterval = 5 * time.Second
+
+// readyzResponse is the JSON body served by /readyz
+type readyzResponse struct {
+	Status                string     `json:"status"`
+	LastSuccessfulProcess *time.Time `json:"lastSuccessfulProcess"`
+}
+
+// newOpsMux returns the handler for the operational endpoints served
+// alongside the CloudEvents receiver
+func newOpsMux(service *Service) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Health check endpoint for observability
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if _, writeErr := w.Write([]byte("OK")); writeErr != nil {
+			// Log but don't fail - health check should be resilient
+			log.Printf("Health check response write failed: %v", writeErr)
+		}
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		resp := readyzResponse{Status: "ready"}
+		if lastSuccess := service.LastSuccess(); !lastSuccess.IsZero() {
+			resp.LastSuccessfulProcess = &lastSuccess
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Events processed before the config can be read would only fail
+		if !service.ConfigReady() {
+			resp.Status = "waiting for config"
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if encodeErr := json.NewEncoder(w).Encode(resp); encodeErr != nil {
+			log.Printf("Readiness response write failed: %v", encodeErr)
+		}
+	})
+
+	mux.Handle("GET /metrics", metricsHandler())
+
+	// Lets an operator close an open circuit breaker once they know the
+	// dependency has recovered. Only accepted from the pod itself, e.g. via
+	// kubectl exec or port-forward.
+	mux.HandleFunc("POST /debug/breakers/{operation}/reset", func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackRequest(r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		operation := r.PathValue("operation")
+		if !service.resetCircuitBreaker(operation) {
+			http.Error(w, fmt.Sprintf("unknown operation: %s", operation), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Shows the config a namespace's snapshots are processed with, and how
+	// many snapshots each namespace had, when DEBUG_ENDPOINTS_ENABLED is set.
+	// Also only accepted from the pod itself.
+	if service.debugEndpoints {
+		mux.HandleFunc("GET /debug/config", service.serveDebugConfig)
+		mux.HandleFunc("GET /debug/stats", service.serveDebugStats)
+	}
+
+	return mux
+}
+
+// isLoopbackRequest reports whether the request came from localhost
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		log.Fatalf("Failed to create service: %v", err)
+		return false
 	}
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ReceiverConfig configures the CloudEvents HTTP receiver
+type ReceiverConfig struct {
+	// Path events are accepted on, defaults to "/"
+	Path string
+	// Port to listen on, defaults to 8080. Ignored when Listener is set.
+	Port int
+	// Listener to serve on instead of opening Port
+	Listener net.Listener
+	// TLSConfig serves the receiver over TLS when set
+	TLSConfig *tls.Config
+	// RequestTimeout bounds how long a request may take before a 504 is
+	// returned, zero disables the timeout
+	RequestTimeout time.Duration
+	// MaxBodyBytes bounds the size of an event body, defaults to
+	// defaultMaxEventBodyBytes
+	MaxBodyBytes int64
+	// Ops serves the operational endpoints alongside the receiver
+	Ops *http.ServeMux
+	// HandleEvent processes each event of a batched delivery
+	HandleEvent func(context.Context, cloudevents.Event) error
+	// Middleware is applied around the built-in receiver middleware, the
+	// last entry being outermost
+	Middleware []cehttp.Middleware
+}
+
+// NewCloudEventsReceiver builds the CloudEvents client that receives events
+// over HTTP, with the operational endpoints, body limit, batch handling and
+// type filter in front of it
+func NewCloudEventsReceiver(config ReceiverConfig) (CloudEventsClient, error) {
+	if config.Path == "" {
+		config.Path = "/"
 	}
-	protocol, err := cehttp.New(
-		cehttp.WithPath("/"),
-		cehttp.WithMiddleware(func(next http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Health check endpoint for observability
-				if r.URL.Path == "/health" && r.Method == "GET" {
-					w.WriteHeader(http.StatusOK)
-					if _, writeErr := w.Write([]byte("OK")); writeErr != nil {
-						// Log but don't fail - health check should be resilient
-						log.Printf("Health check response write failed: %v", writeErr)
-					}
+	if config.Port == 0 {
+		config.Port = 8080
+	}
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = defaultMaxEventBodyBytes
+	}
+
+	opts := []cehttp.Option{
+		cehttp.WithPath(config.Path),
+		cehttp.WithMiddleware(newReceiverMiddleware(config.Ops, config.MaxBodyBytes, config.HandleEvent)),
+	}
+	if config.RequestTimeout > 0 {
+		opts = append(opts, cehttp.WithMiddleware(newTimeoutMiddleware(config.RequestTimeout)))
+	}
+	for _, middleware := range config.Middleware {
+		opts = append(opts, cehttp.WithMiddleware(middleware))
+	}
+	listener := config.Listener
+	if listener == nil && config.TLSConfig != nil {
+		// The port is bound when the receiver starts, where Server.Run
+		// retries a failed bind
+		return &tlsPortReceiver{port: config.Port, tlsConfig: config.TLSConfig, opts: opts}, nil
+	}
+	if listener != nil && config.TLSConfig != nil {
+		listener = tls.NewListener(listener, config.TLSConfig)
+	}
+	if listener != nil {
+		opts = append(opts, cehttp.WithListener(listener))
+	} else {
+		opts = append(opts, cehttp.WithPort(config.Port))
+	}
+	return newCloudEventsClient(opts)
+}
+
+// newCloudEventsClient builds a CloudEvents client over HTTP
+func newCloudEventsClient(opts []cehttp.Option) (*realCloudEventsClient, error) {
+	protocol, err := cehttp.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create protocol: %w", err)
+	}
+	ceClient, err := ceclient.New(protocol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CloudEvents client: %w", err)
+	}
+	return &realCloudEventsClient{client: ceClient}, nil
+}
+
+// tlsPortReceiver serves the receiver over TLS on a port. Without TLS the
+// CloudEvents protocol binds the port itself when started, this does the
+// same for the TLS listener, so a port that's still in use is retried.
+type tlsPortReceiver struct {
+	port      int
+	tlsConfig *tls.Config
+	opts      []cehttp.Option
+}
+
+func (r *tlsPortReceiver) StartReceiver(ctx context.Context, fn interface{}) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", r.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", r.port, err)
+	}
+	receiver, err := newCloudEventsClient(append(slices.Clone(r.opts), cehttp.WithListener(tls.NewListener(listener, r.tlsConfig))))
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	return receiver.StartReceiver(ctx, fn)
+}
+
+// newServiceReceiver returns the CloudEvents receiver in front of the
+// service, serving its operational endpoints and handing it batched events
+func newServiceReceiver(service *Service, config ReceiverConfig) (CloudEventsClient, error) {
+	config.Ops = newOpsMux(service)
+	config.HandleEvent = service.handleCloudEvent
+	return NewCloudEventsReceiver(config)
+}
+
+// newReceiverMiddleware returns the HTTP middleware wrapped around the
+// CloudEvents receiver. It serves the operational endpoints, enforces the
+// maximum body size, unpacks batched deliveries and drops events of types we
+// don't care about.
+func newReceiverMiddleware(ops *http.ServeMux, maxBodyBytes int64, handleEvent func(context.Context, cloudevents.Event) error) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ops != nil {
+				if handler, pattern := ops.Handler(r); pattern != "" {
+					handler.ServeHTTP(w, r)
 					return
 				}
+			}
 
-				if r.Header.Get("Ce-Type") != "dev.knative.apiserver.resource.add" {
-					w.WriteHeader(http.StatusAccepted)
+			// Enforce the body size limit before the CloudEvents SDK gets a
+			// chance to read (and allocate) the whole request
+			if r.ContentLength > maxBodyBytes {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+				if err != nil {
+					var maxBytesErr *http.MaxBytesError
+					if errors.As(err, &maxBytesErr) {
+						http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+						return
+					}
+					http.Error(w, "failed to read request body", http.StatusBadRequest)
 					return
 				}
-				next.ServeHTTP(w, r)
-			})
-		}),
-	)
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			// The SDK receiver only understands single events, so batches are
+			// unpacked and dispatched one event at a time here
+			if cehttp.IsHTTPBatch(r.Header) {
+				handleBatch(w, r, handleEvent)
+				return
+			}
+
+			if r.Header.Get("Ce-Type") != apiServerAddEventType {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleBatch processes each event in an application/cloudevents-batch+json
+// delivery. Failures are aggregated so that a partial failure results in an
+// error response and the batch gets redelivered.
+func handleBatch(w http.ResponseWriter, r *http.Request, handleEvent func(context.Context, cloudevents.Event) error) {
+	events, err := cehttp.NewEventsFromHTTPRequest(r)
 	if err != nil {
-		log.Fatalf("Failed to create protocol: %v", err)
+		http.Error(w, fmt.Sprintf("failed to parse event batch: %v", err), http.StatusBadRequest)
+		return
 	}
-	ceClient, err := ceclient.New(protocol)
+
+	var errs []error
+	for _, event := range events {
+		if event.Type() != apiServerAddEventType {
+			continue
+		}
+		if handleErr := handleEvent(r.Context(), event); handleErr != nil {
+			errs = append(errs, fmt.Errorf("event %s: %w", event.ID(), handleErr))
+		}
+	}
+	if joinedErr := errors.Join(errs...); joinedErr != nil {
+		log.Printf("Failed to process %d of %d batched events: %v", len(errs), len(events), joinedErr)
+		http.Error(w, joinedErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// newTimeoutMiddleware cancels the request context after timeout and replies
+// with a 504, freeing the connection even if the handler is still running.
+// Handler output is buffered so nothing it writes after the timeout reaches
+// the client.
+func newTimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				_, _ = w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					log.Printf("Request to %s timed out after %s", r.URL.Path, timeout)
+					http.Error(w, "request timed out", http.StatusGatewayTimeout)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a handler's response until newTimeoutMiddleware
+// decides whether to send it
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(runValidateConfig(context.Background(), os.Args[2:], os.Stdout))
+	}
+	requestTimeout := time.Duration(getEnvInt64("REQUEST_TIMEOUT_SECONDS", 0)) * time.Second
+	outboundClient := newOutboundHTTPClient(time.Duration(getEnvInt64("OUTBOUND_HTTP_TIMEOUT_SECONDS", int64(defaultOutboundTimeout/time.Second))) * time.Second)
+	var eventSender EventSender
+	if emit, _ := strconv.ParseBool(os.Getenv("EMIT_TASKRUN_CREATED_EVENTS")); emit {
+		sinkURL := os.Getenv("SINK_URL")
+		if sinkURL == "" {
+			log.Fatalf("SINK_URL must be set when EMIT_TASKRUN_CREATED_EVENTS is enabled")
+		}
+		var err error
+		if eventSender, err = NewEventSender(sinkURL, outboundClient); err != nil {
+			log.Fatalf("Failed to create event sender: %v", err)
+		}
+	}
+	eventMode, err := parseEventMode(os.Getenv("EVENT_MODE"))
+	if err != nil {
+		log.Fatalf("Invalid event mode: %v", err)
+	}
+	eventResourcePath, err := parseEventResourcePath(os.Getenv("EVENT_RESOURCE_PATH"))
+	if err != nil {
+		log.Fatalf("Invalid event resource path: %v", err)
+	}
+	tektonVersion, err := parseTektonAPIVersion(os.Getenv("TEKTON_API_VERSION"))
+	if err != nil {
+		log.Fatalf("Invalid Tekton API version: %v", err)
+	}
+	serviceConfig := serviceConfigFromEnv()
+	serviceConfig.EventMode = eventMode
+	serviceConfig.EventResourcePath = eventResourcePath
+	serviceConfig.TektonAPIVersion = tektonVersion
+	serviceConfig.RequestTimeout = requestTimeout
+	serviceConfig.EventSender = eventSender
+	if deadLetterSinkURL := os.Getenv("DEAD_LETTER_SINK_URL"); deadLetterSinkURL != "" {
+		if serviceConfig.MaxRedeliveries == 0 {
+			log.Fatalf("MAX_REDELIVERIES must be set when DEAD_LETTER_SINK_URL is set")
+		}
+		if serviceConfig.DeadLetterSender, err = NewEventSender(deadLetterSinkURL, outboundClient); err != nil {
+			log.Fatalf("Failed to create dead letter sender: %v", err)
+		}
+	}
+	serviceConfig.DebugEndpointsEnabled, _ = strconv.ParseBool(os.Getenv("DEBUG_ENDPOINTS_ENABLED"))
+	serviceConfig.IgnoreMissingConfigMaps, _ = strconv.ParseBool(os.Getenv("IGNORE_MISSING_CONFIG_MAPS"))
+	serviceConfig.ConcurrentPolicyLookup, _ = strconv.ParseBool(os.Getenv("CONCURRENT_POLICY_LOOKUP"))
+	service, err := NewService(serviceConfig)
+	if err != nil {
+		log.Fatalf("Failed to create service: %v", err)
+	}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		log.Fatalf("Invalid PORT %q: %v", port, err)
+	}
+	tlsConfig, err := loadTLSConfig(os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE"), os.Getenv("TLS_CLIENT_CA_FILE"))
 	if err != nil {
-		log.Fatalf("Failed to create CloudEvents client: %v", err)
+		log.Fatalf("Invalid TLS configuration: %v", err)
 	}
-	server := NewServer(service, port, &realCloudEventsClient{client: ceClient})
-	if err := server.Start(); err != nil {
+	ceClient, err := newServiceReceiver(service, ReceiverConfig{
+		Port:           portNumber,
+		TLSConfig:      tlsConfig,
+		RequestTimeout: requestTimeout,
+		MaxBodyBytes:   getEnvInt64("MAX_EVENT_BODY_BYTES", defaultMaxEventBodyBytes),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create CloudEvents receiver: %v", err)
+	}
+	// Stop receiving and shut down background work on SIGTERM/SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if dir := os.Getenv("EVENT_LOG_DIR"); dir != "" {
+		eventLog, err := newEventLog(dir, int(getEnvInt64("EVENT_LOG_MAX_ENTRIES", defaultEventLogMaxEntries)))
+		if err != nil {
+			log.Fatalf("Failed to open event log: %v", err)
+		}
+		service.useEventLog(eventLog)
+	}
+
+	podNamespace := os.Getenv("POD_NAMESPACE")
+	if podNamespace == "" {
+		podNamespace = "default"
+	}
+	reap, _ := strconv.ParseBool(os.Getenv("REAP_COMPLETED_TASKRUNS"))
+	permissions := requiredPermissions(podNamespace, reap)
+
+	if audit, _ := strconv.ParseBool(os.Getenv("AUDIT_ENABLED")); audit {
+		auditNamespace := os.Getenv("AUDIT_NAMESPACE")
+		if auditNamespace == "" {
+			auditNamespace = podNamespace
+		}
+		configMaps, err := newAuditConfigMaps(auditNamespace)
+		if err != nil {
+			log.Fatalf("Failed to create audit log: %v", err)
+		}
+		service.useAuditLog(newAuditLog(configMaps, auditNamespace, os.Getenv("AUDIT_CONFIGMAP_NAME"),
+			int(getEnvInt64("AUDIT_MAX_RECORDS", defaultAuditMaxRecords))))
+		permissions = append(permissions, auditPermissions(auditNamespace)...)
+	}
+
+	// Missing RBAC otherwise only shows up when an event is processed
+	if err := service.checkPermissions(ctx, permissions); err != nil {
+		if strict, _ := strconv.ParseBool(os.Getenv("STRICT_RBAC_CHECK")); strict {
+			log.Fatalf("RBAC check failed: %v", err)
+		}
+		log.Printf("RBAC check failed, continuing: %v", err)
+	}
+
+	service.startConfigReadinessCheck(podNamespace, defaultConfigReadinessInterval)
+
+	if reap {
+		retention := time.Duration(getEnvInt64("TASKRUN_RETENTION_HOURS", defaultTaskRunRetentionHours)) * time.Hour
+		service.startTaskRunReaper(podNamespace, retention, defaultReapInterval)
+	}
+
+	server := NewServer(service, port, ceClient)
+	server.listenRetryAttempts = int(getEnvInt64("LISTEN_RETRY_ATTEMPTS", 1))
+	err = server.Run(ctx)
+	service.Close()
+	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,72 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsRegistry holds the service's own metrics. A dedicated registry is
+// used rather than the global default so that tests can't be polluted by
+// metrics registered by dependencies.
+var metricsRegistry = prometheus.NewRegistry()
+
+var lastSuccessfulProcessTimestamp = promauto.With(metricsRegistry).NewGauge(prometheus.GaugeOpts{
+	Name: "last_successful_process_timestamp_seconds",
+	Help: "Unix timestamp of the last snapshot that was processed successfully.",
+})
+
+var eventsHandled = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+	Name: "events_handled_total",
+	Help: "Number of events handled, by result: processed, ignored, failed or dead_lettered.",
+}, []string{"result"})
+
+var snapshotsSkipped = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+	Name: "snapshots_skipped_total",
+	Help: "Number of snapshots deliberately not verified, by reason.",
+}, []string{"reason"})
+
+var policyResolutions = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+	Name: "policy_resolution_total",
+	Help: "Number of snapshot policies resolved, by source: rpa, default, override, config or annotation.",
+}, []string{"source"})
+
+// The config cache hit ratio can be derived from the hit and miss counters as
+// hits / (hits + misses)
+var (
+	configMapCacheEntries = promauto.With(metricsRegistry).NewGauge(prometheus.GaugeOpts{
+		Name: "configmap_cache_entries",
+		Help: "Number of namespaces with config held in the ConfigMap cache.",
+	})
+	configMapCacheHits = promauto.With(metricsRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "configmap_cache_hits_total",
+		Help: "Number of config lookups served from the ConfigMap cache.",
+	})
+	configMapCacheMisses = promauto.With(metricsRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "configmap_cache_misses_total",
+		Help: "Number of config lookups that missed the ConfigMap cache, including expired entries.",
+	})
+)
+
+// metricsHandler serves the metrics in the Prometheus exposition format
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,111 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+)
+
+const (
+	// maxNamespaceStats bounds how many namespaces are counted separately
+	maxNamespaceStats = 1000
+	// otherNamespacesKey collects the counts of namespaces seen once
+	// maxNamespaceStats namespaces are already counted
+	otherNamespacesKey = "(other)"
+)
+
+// NamespaceStats counts the snapshots processed for a namespace since the
+// service started
+type NamespaceStats struct {
+	Processed int64 `json:"processed"`
+	Skipped   int64 `json:"skipped"`
+	Failed    int64 `json:"failed"`
+}
+
+// namespaceStats holds the NamespaceStats of every snapshot namespace
+type namespaceStats struct {
+	mu            sync.Mutex
+	byNamespace   map[string]*NamespaceStats
+	maxNamespaces int
+}
+
+func newNamespaceStats(maxNamespaces int) *namespaceStats {
+	return &namespaceStats{
+		byNamespace:   make(map[string]*NamespaceStats),
+		maxNamespaces: maxNamespaces,
+	}
+}
+
+// record counts a snapshot from the namespace with the given process summary
+// outcome
+func (n *namespaceStats) record(namespace, outcome string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats, ok := n.byNamespace[namespace]
+	if !ok {
+		if len(n.byNamespace) >= n.maxNamespaces {
+			namespace = otherNamespacesKey
+		}
+		if stats, ok = n.byNamespace[namespace]; !ok {
+			stats = &NamespaceStats{}
+			n.byNamespace[namespace] = stats
+		}
+	}
+
+	switch outcome {
+	case outcomeCreated:
+		stats.Processed++
+	case outcomeSkipped:
+		stats.Skipped++
+	default:
+		stats.Failed++
+	}
+}
+
+// snapshot returns a copy of the counts, keyed by namespace
+func (n *namespaceStats) snapshot() map[string]NamespaceStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats := make(map[string]NamespaceStats, len(n.byNamespace))
+	for namespace, s := range n.byNamespace {
+		stats[namespace] = *s
+	}
+	return stats
+}
+
+// debugStatsResponse is the JSON body served by /debug/stats
+type debugStatsResponse struct {
+	Namespaces map[string]NamespaceStats `json:"namespaces"`
+}
+
+// serveDebugStats returns the per-namespace counts of processed snapshots
+func (s *Service) serveDebugStats(w http.ResponseWriter, r *http.Request) {
+	if !isLoopbackRequest(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if encodeErr := json.NewEncoder(w).Encode(debugStatsResponse{Namespaces: s.namespaceStats.snapshot()}); encodeErr != nil {
+		log.Printf("Debug stats response write failed: %v", encodeErr)
+	}
+}
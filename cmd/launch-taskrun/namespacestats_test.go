@@ -0,0 +1,157 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/konflux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceStats_Concurrent(t *testing.T) {
+	const (
+		namespaces = 8
+		workers    = 16
+		iterations = 200
+	)
+	stats := newNamespaceStats(maxNamespaceStats)
+	outcomes := []string{outcomeCreated, outcomeSkipped, outcomeFailed}
+
+	var wg sync.WaitGroup
+	for worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				namespace := fmt.Sprintf("namespace-%d", (worker+i)%namespaces)
+				stats.record(namespace, outcomes[i%len(outcomes)])
+				// Reading while others write must be safe too
+				_ = stats.snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total NamespaceStats
+	result := stats.snapshot()
+	require.Len(t, result, namespaces)
+	for _, s := range result {
+		total.Processed += s.Processed
+		total.Skipped += s.Skipped
+		total.Failed += s.Failed
+	}
+	// Every worker records the three outcomes in turn
+	assert.Equal(t, int64(workers)*((iterations+2)/3), total.Processed)
+	assert.Equal(t, int64(workers)*((iterations+1)/3), total.Skipped)
+	assert.Equal(t, int64(workers)*(iterations/3), total.Failed)
+}
+
+func TestNamespaceStats_Bounded(t *testing.T) {
+	stats := newNamespaceStats(2)
+
+	stats.record("namespace-1", outcomeCreated)
+	stats.record("namespace-2", outcomeSkipped)
+	stats.record("namespace-3", outcomeFailed)
+	stats.record("namespace-4", outcomeCreated)
+	stats.record("namespace-1", outcomeCreated)
+
+	assert.Equal(t, map[string]NamespaceStats{
+		"namespace-1":      {Processed: 2},
+		"namespace-2":      {Skipped: 1},
+		otherNamespacesKey: {Processed: 1, Failed: 1},
+	}, stats.snapshot())
+}
+
+func TestProcessSnapshot_NamespaceStats(t *testing.T) {
+	const namespaces = 4
+	const perNamespace = 25
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+
+	var wg sync.WaitGroup
+	for n := range namespaces {
+		for i := range perNamespace {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				snapshot := &konflux.Snapshot{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        fmt.Sprintf("snapshot-%d", i),
+						Namespace:   fmt.Sprintf("namespace-%d", n),
+						Annotations: map[string]string{skipAnnotation: "true"},
+					},
+					Spec: json.RawMessage(`{"application":"test-application"}`),
+				}
+				result, err := service.processSnapshot(context.Background(), snapshot)
+				assert.NoError(t, err)
+				assert.Equal(t, resultIgnored, result)
+			}()
+		}
+	}
+	wg.Wait()
+
+	stats := service.namespaceStats.snapshot()
+	require.Len(t, stats, namespaces)
+	for n := range namespaces {
+		assert.Equal(t, NamespaceStats{Skipped: perNamespace}, stats[fmt.Sprintf("namespace-%d", n)])
+	}
+}
+
+func TestDebugStatsEndpoint(t *testing.T) {
+	get := func(mux *http.ServeMux, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/debug/stats", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	newService := func(t *testing.T, enabled bool) *Service {
+		return NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{DebugEndpointsEnabled: enabled})
+	}
+
+	t.Run("returns the counts", func(t *testing.T) {
+		service := newService(t, true)
+		service.namespaceStats.record("namespace-1", outcomeCreated)
+		service.namespaceStats.record("namespace-1", outcomeFailed)
+		service.namespaceStats.record("namespace-2", outcomeSkipped)
+
+		rec := get(newOpsMux(service), "127.0.0.1:12345")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"namespaces":{
+			"namespace-1":{"processed":1,"skipped":0,"failed":1},
+			"namespace-2":{"processed":0,"skipped":1,"failed":0}
+		}}`, rec.Body.String())
+	})
+
+	t.Run("only from the pod itself", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get(newOpsMux(newService(t, true)), "10.0.0.1:12345").Code)
+	})
+
+	t.Run("not served unless enabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get(newOpsMux(newService(t, false)), "127.0.0.1:12345").Code)
+	})
+}
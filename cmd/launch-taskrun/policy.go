@@ -0,0 +1,283 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/konflux"
+	gozap "go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// policyAnnotation on a snapshot names the policy to verify it with, as
+// "namespace/name". It's only honored when POLICY_RESOLUTION_ORDER includes
+// the annotation source, since anyone who can annotate a snapshot could
+// otherwise pick a weaker policy.
+const policyAnnotation = "conforma.dev/policy"
+
+// policySource is somewhere the policy for a snapshot can come from
+type policySource string
+
+const (
+	// policySourceAnnotation is the snapshot's policyAnnotation
+	policySourceAnnotation policySource = "annotation"
+	// policySourceApplication is the APPLICATION_POLICY_OVERRIDES entry for
+	// the snapshot's application
+	policySourceApplication policySource = "application"
+	// policySourceReleasePlan is the policy in the RPA the snapshot would be
+	// released with
+	policySourceReleasePlan policySource = "releaseplan"
+	// policySourceConfig is POLICY_CONFIGURATION
+	policySourceConfig policySource = "config"
+)
+
+// defaultPolicyResolutionOrder is used when POLICY_RESOLUTION_ORDER isn't
+// set. Snapshots matching none of these sources are skipped.
+var defaultPolicyResolutionOrder = []policySource{policySourceApplication, policySourceReleasePlan}
+
+// parsePolicyResolutionOrder reads POLICY_RESOLUTION_ORDER, a comma separated
+// list of policy sources tried in turn
+func parsePolicyResolutionOrder(val string) ([]policySource, error) {
+	if strings.TrimSpace(val) == "" {
+		return defaultPolicyResolutionOrder, nil
+	}
+	var order []policySource
+	for _, entry := range strings.Split(val, ",") {
+		source := policySource(strings.ToLower(strings.TrimSpace(entry)))
+		switch source {
+		case policySourceAnnotation, policySourceApplication, policySourceReleasePlan, policySourceConfig:
+		default:
+			return nil, fmt.Errorf("POLICY_RESOLUTION_ORDER entry %q is not supported, must be annotation, application, releaseplan or config", entry)
+		}
+		if slices.Contains(order, source) {
+			return nil, fmt.Errorf("POLICY_RESOLUTION_ORDER lists %q more than once", source)
+		}
+		order = append(order, source)
+	}
+	return order, nil
+}
+
+// resolvePolicy finds the policy for a snapshot by trying the sources in
+// POLICY_RESOLUTION_ORDER in turn, returning the first one that has a policy
+// and which source it was. If no source has one, the returned source is
+// empty and the snapshot should be skipped.
+func (s *Service) resolvePolicy(ctx context.Context, snapshot *konflux.Snapshot, config *TaskRunConfig) (konflux.ResolvedPolicy, policySource, error) {
+	order, err := parsePolicyResolutionOrder(config.PolicyResolutionOrder)
+	if err != nil {
+		return konflux.ResolvedPolicy{}, "", err
+	}
+	appName, err := snapshot.ApplicationName()
+	if err != nil {
+		return konflux.ResolvedPolicy{}, "", err
+	}
+
+	var lookupErr error
+	for _, source := range order {
+		if appName == "" && (source == policySourceApplication || source == policySourceReleasePlan) {
+			// These sources need the application, the others may still
+			// have a policy for the snapshot
+			mode, err := missingApplicationMode(config)
+			if err != nil {
+				return konflux.ResolvedPolicy{}, "", err
+			}
+			if mode == missingApplicationError {
+				err := fmt.Errorf("snapshot %s/%s has no application", snapshot.Namespace, snapshot.Name)
+				s.logger.Error(err, "Failing snapshot without an application")
+				return konflux.ResolvedPolicy{}, "", err
+			}
+			s.logger.Info("Skipping policy source for snapshot without an application",
+				gozap.String("snapshot", snapshot.Name), gozap.String("source", string(source)))
+			continue
+		}
+
+		var policy konflux.ResolvedPolicy
+		found := false
+		switch source {
+		case policySourceAnnotation:
+			if ref := strings.TrimSpace(snapshot.Annotations[policyAnnotation]); ref != "" {
+				policy, found = konflux.ParsePolicyRef(ref), true
+			}
+		case policySourceApplication:
+			override, overridden, err := applicationPolicyOverride(config, appName)
+			if err != nil {
+				return konflux.ResolvedPolicy{}, "", err
+			}
+			if overridden {
+				policy, found = konflux.ParsePolicyRef(override), true
+			}
+		case policySourceReleasePlan:
+			policy, err = s.findEcp(ctx, snapshot, config)
+			if errors.Is(err, konflux.ErrNoReleasePlan) && s.retryMissingReleasePlan(snapshot, config) {
+				// The ReleasePlan may not have been created yet. Returning an error
+				// gets the event redelivered.
+				return konflux.ResolvedPolicy{}, "", fmt.Errorf("waiting for release plan for snapshot %s/%s: %w", snapshot.Namespace, snapshot.Name, err)
+			}
+			if err != nil && ctx.Err() != nil {
+				// Running out of time says nothing about the ReleasePlan
+				return konflux.ResolvedPolicy{}, "", err
+			}
+			if errors.Is(err, konflux.ErrAmbiguousReleasePlan) {
+				// The user asked for ambiguous ReleasePlans to fail loudly
+				return konflux.ResolvedPolicy{}, "", err
+			}
+			if err != nil {
+				// No ReleasePlan or RPA generally means the snapshot isn't
+				// going to be released, see createTaskRun
+				lookupErr = err
+				s.logger.Info("Unable to find RPA in cluster", gozap.Error(err))
+			} else {
+				found = true
+				s.logger.Info("Found RPA in cluster. Using correct ECP.", gozap.Bool("defaultPolicy", policy.IsDefault))
+			}
+		case policySourceConfig:
+			// POLICY_CONFIGURATION can be any policy reference Conforma
+			// accepts, such as a git URL, so it's used as it is
+			if ref := strings.TrimSpace(config.PolicyConfiguration); ref != "" {
+				policy, found = konflux.ResolvedPolicy{Name: ref}, true
+			}
+		}
+		if found {
+			policyResolutions.WithLabelValues(policyResolutionLabel(source, policy)).Inc()
+			s.logger.Info("Resolved policy",
+				gozap.String("snapshot", snapshot.Name),
+				gozap.String("application", appName),
+				gozap.String("source", string(source)),
+				gozap.String("policy", policy.String()))
+			return policy, source, nil
+		}
+	}
+
+	s.logger.Info("No policy source matched the snapshot",
+		gozap.String("snapshot", snapshot.Name),
+		gozap.Any("order", order),
+		gozap.NamedError("lookupError", lookupErr))
+	return konflux.ResolvedPolicy{}, "", nil
+}
+
+// policyResolutionLabel is the source label of policy_resolution_total for a
+// policy resolved from source. An RPA without a policy of its own counts as
+// "default" rather than "rpa".
+func policyResolutionLabel(source policySource, policy konflux.ResolvedPolicy) string {
+	switch source {
+	case policySourceReleasePlan:
+		if policy.IsDefault {
+			return "default"
+		}
+		return "rpa"
+	case policySourceApplication:
+		return "override"
+	default:
+		return string(source)
+	}
+}
+
+// verifyPolicyExists checks the EnterpriseContractPolicy a TaskRun would be
+// created with is in the cluster. Only policies given as "namespace/name" can
+// be checked, others such as a git URL in POLICY_CONFIGURATION are assumed to
+// exist. A missing policy isn't retried and the returned error wraps the
+// NotFound error.
+func (s *Service) verifyPolicyExists(ctx context.Context, config *TaskRunConfig, policy konflux.ResolvedPolicy) error {
+	if policy.Namespace == "" {
+		s.logger.Debug("Not checking a policy that isn't in the cluster exists", gozap.String("policy", policy.String()))
+		return nil
+	}
+	err := s.retryK8sWithBackoff(ctx, config, "get-policy", func() error {
+		return s.crtlClient.Get(ctx, client.ObjectKey{Namespace: policy.Namespace, Name: policy.Name}, &konflux.EnterpriseContractPolicy{})
+	})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("policy %s does not exist: %w", policy, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check policy %s exists: %w", policy, err)
+	}
+	return nil
+}
+
+// prefetchedEcp is the result of a ReleasePlan policy lookup made while the
+// config was being read, and the options it was made with
+type prefetchedEcp struct {
+	opts   konflux.LookupOptions
+	policy konflux.ResolvedPolicy
+	err    error
+}
+
+type prefetchedEcpKey struct{}
+
+// withPrefetchedEcp records a prefetched lookup in the context for findEcp
+func withPrefetchedEcp(ctx context.Context, prefetched *prefetchedEcp) context.Context {
+	return context.WithValue(ctx, prefetchedEcpKey{}, prefetched)
+}
+
+// prefetchedEcpFrom returns the lookup recorded by withPrefetchedEcp, if any
+func prefetchedEcpFrom(ctx context.Context) (*prefetchedEcp, bool) {
+	prefetched, ok := ctx.Value(prefetchedEcpKey{}).(*prefetchedEcp)
+	return prefetched, ok
+}
+
+// readConfigAndPrefetchEcp reads the config map and, with
+// CONCURRENT_POLICY_LOOKUP set, looks up the snapshot's ReleasePlan policy at
+// the same time. The config isn't known yet, so the lookup uses the default
+// lookup options and retry settings. findEcp only uses its result if the
+// config asks for the same lookup options, and looks again otherwise.
+//
+// A failed config read cancels the lookup and its error is returned. Lookup
+// errors are returned in the prefetched result instead, to be handled by
+// resolvePolicy as if the lookup had been made then, as it may not need the
+// ReleasePlan policy at all. Only running out of time or an ambiguous
+// ReleasePlan, which resolvePolicy would fail on anyway, cancel the config
+// read.
+func (s *Service) readConfigAndPrefetchEcp(ctx context.Context, namespace string, snapshot *konflux.Snapshot) (*TaskRunConfig, bool, *prefetchedEcp, error) {
+	if !s.concurrentPolicyLookup {
+		config, cached, err := s.readConfigMapCached(ctx, namespace)
+		return config, cached, nil, err
+	}
+
+	var config *TaskRunConfig
+	var cached bool
+	prefetchConfig := &TaskRunConfig{}
+	opts, err := releasePlanLookupOptions(prefetchConfig)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	prefetched := &prefetchedEcp{opts: opts}
+
+	// Each goroutine only writes its own results, which are read after Wait
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		config, cached, err = s.readConfigMapCached(groupCtx, namespace)
+		return err
+	})
+	group.Go(func() error {
+		prefetched.policy, prefetched.err = s.findEcp(groupCtx, snapshot, prefetchConfig)
+		if prefetched.err != nil && (ctx.Err() != nil || errors.Is(prefetched.err, konflux.ErrAmbiguousReleasePlan)) {
+			return fmt.Errorf("failed to look up the policy: %w", prefetched.err)
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, false, nil, err
+	}
+	return config, cached, prefetched, nil
+}
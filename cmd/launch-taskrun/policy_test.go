@@ -0,0 +1,294 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/konflux"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParsePolicyResolutionOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []policySource
+		wantErr  string
+	}{
+		{name: "default", value: "", expected: defaultPolicyResolutionOrder},
+		{
+			name:     "custom",
+			value:    " Annotation, releaseplan,config ",
+			expected: []policySource{policySourceAnnotation, policySourceReleasePlan, policySourceConfig},
+		},
+		{name: "unknown source", value: "annotation,label", wantErr: `entry "label" is not supported`},
+		{name: "duplicate source", value: "config,config", wantErr: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := parsePolicyResolutionOrder(tt.value)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, order)
+		})
+	}
+}
+
+func TestResolvePolicy(t *testing.T) {
+	allSources := "annotation,application,releaseplan,config"
+
+	tests := []struct {
+		name           string
+		order          string
+		annotated      bool
+		overrides      string
+		releasePlan    bool
+		expectedSource policySource
+		expectedPolicy string
+	}{
+		{
+			name:           "annotation wins",
+			order:          allSources,
+			annotated:      true,
+			overrides:      `{"test-application":"override-ns/override-policy"}`,
+			releasePlan:    true,
+			expectedSource: policySourceAnnotation,
+			expectedPolicy: "annotated-ns/annotated-policy",
+		},
+		{
+			name:           "application override wins",
+			order:          allSources,
+			overrides:      `{"test-application":"override-ns/override-policy"}`,
+			releasePlan:    true,
+			expectedSource: policySourceApplication,
+			expectedPolicy: "override-ns/override-policy",
+		},
+		{
+			name:           "release plan wins",
+			order:          allSources,
+			overrides:      `{"other-application":"override-ns/override-policy"}`,
+			releasePlan:    true,
+			expectedSource: policySourceReleasePlan,
+			expectedPolicy: "test-target/test-ecp-policy",
+		},
+		{
+			name:           "config wins",
+			order:          allSources,
+			expectedSource: policySourceConfig,
+			expectedPolicy: "github.com/conforma/config//slsa3",
+		},
+		{
+			name:           "order is respected",
+			order:          "config,annotation",
+			annotated:      true,
+			expectedSource: policySourceConfig,
+			expectedPolicy: "github.com/conforma/config//slsa3",
+		},
+		{
+			name:           "annotation ignored by default",
+			annotated:      true,
+			releasePlan:    true,
+			expectedSource: policySourceReleasePlan,
+			expectedPolicy: "test-target/test-ecp-policy",
+		},
+		{
+			name:           "config not used by default",
+			expectedSource: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCrtlClient := &mockControllerRuntimeClient{}
+			if tt.releasePlan {
+				setupSuccessfulECPLookupMocks(mockCrtlClient, "test-application", "test-namespace", "test-target")
+			} else {
+				// An empty list means there's no ReleasePlan
+				mockCrtlClient.On("List", mock.Anything, mock.AnythingOfType("*konflux.ReleasePlanList"), mock.Anything).Return(nil)
+			}
+			service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+
+			snapshot := &konflux.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-snapshot", Namespace: "test-namespace"},
+				Spec:       json.RawMessage(`{"application":"test-application"}`),
+			}
+			if tt.annotated {
+				snapshot.Annotations = map[string]string{policyAnnotation: "annotated-ns/annotated-policy"}
+			}
+			config := &TaskRunConfig{
+				PolicyConfiguration:        "github.com/conforma/config//slsa3",
+				ApplicationPolicyOverrides: tt.overrides,
+				PolicyResolutionOrder:      tt.order,
+				K8sRetryAttempts:           "1",
+			}
+
+			policy, source, err := service.resolvePolicy(context.Background(), snapshot, config)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSource, source)
+			if tt.expectedSource != "" {
+				assert.Equal(t, tt.expectedPolicy, policy.String())
+			}
+		})
+	}
+}
+
+func TestResolvePolicy_Metrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     string
+		annotated bool
+		overrides string
+		rpaPolicy string
+		expected  string
+	}{
+		{name: "rpa", order: "releaseplan", rpaPolicy: "test-ecp-policy", expected: "rpa"},
+		{name: "rpa without a policy", order: "releaseplan", expected: "default"},
+		{name: "application override", order: "application", overrides: `{"test-application":"override-ns/override-policy"}`, expected: "override"},
+		{name: "config", order: "config", expected: "config"},
+		{name: "annotation", order: "annotation", annotated: true, expected: "annotation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCrtlClient := &mockControllerRuntimeClient{}
+			// Registered first so it's matched before the helper's RPA
+			mockCrtlClient.On("Get", mock.Anything, mock.Anything, mock.AnythingOfType("*konflux.ReleasePlanAdmission"), mock.Anything).Run(func(args mock.Arguments) {
+				rpa := args.Get(2).(*konflux.ReleasePlanAdmission)
+				rpa.Name = "test-rpa"
+				rpa.Namespace = "test-target"
+				rpa.Spec.Policy = tt.rpaPolicy
+			}).Return(nil)
+			setupSuccessfulECPLookupMocks(mockCrtlClient, "test-application", "test-namespace", "test-target")
+			service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+			snapshot := &konflux.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-snapshot", Namespace: "test-namespace"},
+				Spec:       json.RawMessage(`{"application":"test-application"}`),
+			}
+			if tt.annotated {
+				snapshot.Annotations = map[string]string{policyAnnotation: "annotated-ns/annotated-policy"}
+			}
+			config := &TaskRunConfig{
+				PolicyConfiguration:        "github.com/conforma/config//slsa3",
+				ApplicationPolicyOverrides: tt.overrides,
+				PolicyResolutionOrder:      tt.order,
+			}
+			before := map[string]float64{}
+			for _, label := range []string{"rpa", "default", "override", "config", "annotation"} {
+				before[label] = promtestutil.ToFloat64(policyResolutions.WithLabelValues(label))
+			}
+
+			_, source, err := service.resolvePolicy(context.Background(), snapshot, config)
+
+			require.NoError(t, err)
+			require.NotEmpty(t, source)
+			for label, count := range before {
+				expected := count
+				if label == tt.expected {
+					expected++
+				}
+				assert.Equal(t, expected, promtestutil.ToFloat64(policyResolutions.WithLabelValues(label)), label)
+			}
+		})
+	}
+}
+
+func TestResolvePolicy_InvalidOrder(t *testing.T) {
+	mockCrtlClient := &mockControllerRuntimeClient{}
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	snapshot := &konflux.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-snapshot", Namespace: "test-namespace"},
+		Spec:       json.RawMessage(`{"application":"test-application"}`),
+	}
+
+	_, _, err := service.resolvePolicy(context.Background(), snapshot, &TaskRunConfig{PolicyResolutionOrder: "rpa"})
+
+	assert.ErrorContains(t, err, "POLICY_RESOLUTION_ORDER")
+	mockCrtlClient.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateTaskRun_VerifyPolicyExists(t *testing.T) {
+	snapshot := &konflux.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-snapshot", Namespace: "test-namespace"},
+		Spec:       json.RawMessage(`{"application":"test-app"}`),
+	}
+	newConfig := func() *TaskRunConfig {
+		return &TaskRunConfig{
+			TaskName:           "generate-vsa",
+			VsaUploadUrl:       "https://test-upload.example.com",
+			VerifyPolicyExists: "true",
+			K8sRetryAttempts:   "1",
+		}
+	}
+	policyKey := client.ObjectKey{Namespace: "test-target", Name: "test-ecp-policy"}
+
+	t.Run("policy exists", func(t *testing.T) {
+		mockCrtlClient := &mockControllerRuntimeClient{}
+		service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+		setupSuccessfulECPLookupMocks(mockCrtlClient, "test-app", "test-namespace", "test-target")
+		mockCrtlClient.On("Get", mock.Anything, policyKey, mock.AnythingOfType("*konflux.EnterpriseContractPolicy"), mock.Anything).Return(nil)
+
+		taskRun, err := service.createTaskRun(context.Background(), snapshot, newConfig(), "test-namespace")
+
+		require.NoError(t, err)
+		assert.NotNil(t, taskRun)
+		mockCrtlClient.AssertCalled(t, "Get", mock.Anything, policyKey, mock.AnythingOfType("*konflux.EnterpriseContractPolicy"), mock.Anything)
+	})
+
+	t.Run("policy missing", func(t *testing.T) {
+		mockCrtlClient := &mockControllerRuntimeClient{}
+		service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+		setupSuccessfulECPLookupMocks(mockCrtlClient, "test-app", "test-namespace", "test-target")
+		mockCrtlClient.On("Get", mock.Anything, policyKey, mock.AnythingOfType("*konflux.EnterpriseContractPolicy"), mock.Anything).
+			Return(apierrors.NewNotFound(schema.GroupResource{Group: "appstudio.redhat.com", Resource: "enterprisecontractpolicies"}, "test-ecp-policy"))
+
+		taskRun, err := service.createTaskRun(context.Background(), snapshot, newConfig(), "test-namespace")
+
+		assert.EqualError(t, err, `policy test-target/test-ecp-policy does not exist: enterprisecontractpolicies.appstudio.redhat.com "test-ecp-policy" not found`)
+		assert.True(t, apierrors.IsNotFound(err))
+		assert.Nil(t, taskRun)
+	})
+
+	t.Run("policy outside the cluster isn't checked", func(t *testing.T) {
+		mockCrtlClient := &mockControllerRuntimeClient{}
+		service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, mockCrtlClient, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+		config := newConfig()
+		config.PolicyResolutionOrder = "config"
+		config.PolicyConfiguration = "github.com/org/policy"
+
+		taskRun, err := service.createTaskRun(context.Background(), snapshot, config, "test-namespace")
+
+		require.NoError(t, err)
+		assert.NotNil(t, taskRun)
+		mockCrtlClient.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gozap "go.uber.org/zap"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rbacPermission is an action the service account needs to be allowed. An
+// empty namespace means across all namespaces.
+type rbacPermission struct {
+	verb      string
+	group     string
+	resource  string
+	namespace string
+}
+
+func (p rbacPermission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource = p.resource + "." + p.group
+	}
+	if p.namespace == "" {
+		return fmt.Sprintf("%s %s in all namespaces", p.verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", p.verb, resource, p.namespace)
+}
+
+// requiredPermissions lists what the service does with the Kubernetes API.
+// The config and TaskRuns live in the service's namespace, while snapshots
+// can come from any namespace so the Konflux resources and secrets are read
+// cluster wide. Listing and deleting TaskRuns is only needed by the reaper.
+func requiredPermissions(namespace string, reaping bool) []rbacPermission {
+	permissions := []rbacPermission{
+		{verb: "get", resource: "configmaps", namespace: namespace},
+		{verb: "create", group: "tekton.dev", resource: "taskruns", namespace: namespace},
+		{verb: "list", group: "appstudio.redhat.com", resource: "releaseplans"},
+		{verb: "get", group: "appstudio.redhat.com", resource: "releaseplanadmissions"},
+		{verb: "get", resource: "secrets"},
+	}
+	if reaping {
+		permissions = append(permissions,
+			rbacPermission{verb: "list", group: "tekton.dev", resource: "taskruns", namespace: namespace},
+			rbacPermission{verb: "delete", group: "tekton.dev", resource: "taskruns", namespace: namespace},
+		)
+	}
+	return permissions
+}
+
+// checkPermissions asks the API server whether the service account has each
+// of the permissions, logging the ones that are denied. It returns an error
+// listing them, or the first error from the API server.
+func (s *Service) checkPermissions(ctx context.Context, permissions []rbacPermission) error {
+	reviews := s.k8sClient.AuthorizationV1().SelfSubjectAccessReviews()
+	var denied []string
+	for _, p := range permissions {
+		review, err := reviews.Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: p.namespace,
+					Verb:      p.verb,
+					Group:     p.group,
+					Resource:  p.resource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to check permission to %s: %w", p, err)
+		}
+		if !review.Status.Allowed {
+			s.logger.Warn("Service account is missing a required permission, check the RBAC configuration",
+				gozap.String("permission", p.String()),
+				gozap.String("reason", review.Status.Reason))
+			denied = append(denied, p.String())
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("service account is not allowed to %s", strings.Join(denied, ", "))
+	}
+	s.logger.Info("Service account has the required permissions")
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeAuthorization answers access reviews, denying the listed permissions
+type fakeAuthorization struct {
+	denied   map[rbacPermission]bool
+	err      error
+	reviewed []rbacPermission
+}
+
+func (f *fakeAuthorization) SelfSubjectAccessReviews() K8sSelfSubjectAccessReviewer { return f }
+
+func (f *fakeAuthorization) Create(ctx context.Context, review *authorizationv1.SelfSubjectAccessReview, opts metav1.CreateOptions) (*authorizationv1.SelfSubjectAccessReview, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	attrs := review.Spec.ResourceAttributes
+	p := rbacPermission{verb: attrs.Verb, group: attrs.Group, resource: attrs.Resource, namespace: attrs.Namespace}
+	f.reviewed = append(f.reviewed, p)
+	review.Status.Allowed = !f.denied[p]
+	if !review.Status.Allowed {
+		review.Status.Reason = "no RBAC policy matched"
+	}
+	return review, nil
+}
+
+func newRBACTestService(t *testing.T, authorization *fakeAuthorization) *Service {
+	mockK8s := &mockK8sClient{}
+	mockK8s.On("AuthorizationV1").Return(authorization)
+	return NewServiceWithDependencies(mockK8s, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+}
+
+func TestCheckPermissions(t *testing.T) {
+	t.Run("all allowed", func(t *testing.T) {
+		authorization := &fakeAuthorization{}
+		service := newRBACTestService(t, authorization)
+
+		err := service.checkPermissions(context.Background(), requiredPermissions("test-namespace", false))
+
+		assert.NoError(t, err)
+		assert.Equal(t, requiredPermissions("test-namespace", false), authorization.reviewed)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		authorization := &fakeAuthorization{denied: map[rbacPermission]bool{
+			{verb: "list", group: "appstudio.redhat.com", resource: "releaseplans"}:                  true,
+			{verb: "create", group: "tekton.dev", resource: "taskruns", namespace: "test-namespace"}: true,
+		}}
+		service := newRBACTestService(t, authorization)
+
+		err := service.checkPermissions(context.Background(), requiredPermissions("test-namespace", false))
+
+		assert.EqualError(t, err, "service account is not allowed to "+
+			"create taskruns.tekton.dev in namespace test-namespace, "+
+			"list releaseplans.appstudio.redhat.com in all namespaces")
+	})
+
+	t.Run("review fails", func(t *testing.T) {
+		service := newRBACTestService(t, &fakeAuthorization{err: errors.New("forbidden")})
+
+		err := service.checkPermissions(context.Background(), requiredPermissions("test-namespace", false))
+
+		assert.ErrorContains(t, err, "failed to check permission to get configmaps in namespace test-namespace: forbidden")
+	})
+}
+
+func TestRequiredPermissions_Reaper(t *testing.T) {
+	withoutReaper := requiredPermissions("test-namespace", false)
+	withReaper := requiredPermissions("test-namespace", true)
+
+	assert.NotContains(t, withoutReaper, rbacPermission{verb: "delete", group: "tekton.dev", resource: "taskruns", namespace: "test-namespace"})
+	assert.Contains(t, withReaper, rbacPermission{verb: "delete", group: "tekton.dev", resource: "taskruns", namespace: "test-namespace"})
+	assert.Contains(t, withReaper, rbacPermission{verb: "list", group: "tekton.dev", resource: "taskruns", namespace: "test-namespace"})
+}
@@ -0,0 +1,106 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	gozap "go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Defaults for the optional reaper that deletes old completed TaskRuns,
+// enabled with REAP_COMPLETED_TASKRUNS
+const (
+	defaultTaskRunRetentionHours = 24
+	defaultReapInterval          = 15 * time.Minute
+)
+
+// managedTaskRunSelector matches the TaskRuns created by this service
+var managedTaskRunSelector = fmt.Sprintf("%s=%s,%s", managedByLabel, managedByValue, instanceLabel)
+
+// startTaskRunReaper periodically deletes completed TaskRuns in the namespace
+// once they're older than the retention window. It runs until the service is
+// closed.
+func (s *Service) startTaskRunReaper(namespace string, retention, interval time.Duration) {
+	s.logger.Info("Starting TaskRun reaper",
+		gozap.String("namespace", namespace),
+		gozap.Duration("retention", retention),
+		gozap.Duration("interval", interval))
+
+	s.runInBackground(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.reapCompletedTaskRuns(ctx, namespace, retention); err != nil {
+				s.logger.Error(err, "Failed to reap completed TaskRuns", gozap.String("namespace", namespace))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// reapCompletedTaskRuns deletes the managed TaskRuns in the namespace that
+// completed more than retention ago, returning how many were deleted
+func (s *Service) reapCompletedTaskRuns(ctx context.Context, namespace string, retention time.Duration) (int, error) {
+	taskRuns := s.tektonClient.TektonV1().TaskRuns(namespace)
+	list, err := taskRuns.List(ctx, metav1.ListOptions{LabelSelector: managedTaskRunSelector})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list TaskRuns in namespace %s: %w", namespace, err)
+	}
+
+	deleted := 0
+	cutoff := time.Now().Add(-retention)
+	for i := range list.Items {
+		taskRun := &list.Items[i]
+		if !isReapable(taskRun, cutoff) {
+			continue
+		}
+		err := taskRuns.Delete(ctx, taskRun.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			s.logger.Error(err, "Failed to delete completed TaskRun", gozap.String("name", taskRun.Name))
+			continue
+		}
+		deleted++
+		s.logger.Info("Deleted completed TaskRun",
+			gozap.String("name", taskRun.Name),
+			gozap.String("namespace", namespace),
+			gozap.Time("completionTime", taskRun.Status.CompletionTime.Time))
+	}
+	return deleted, nil
+}
+
+// isReapable reports whether a TaskRun is one of ours and completed before
+// the cutoff. The labels are checked again here rather than trusting the
+// list selector alone, since deleting someone else's TaskRun would be bad.
+func isReapable(taskRun *tektonv1.TaskRun, cutoff time.Time) bool {
+	if taskRun.Labels[managedByLabel] != managedByValue || taskRun.Labels[instanceLabel] == "" {
+		return false
+	}
+	if !taskRun.IsDone() || taskRun.Status.CompletionTime == nil {
+		return false
+	}
+	return taskRun.Status.CompletionTime.Time.Before(cutoff)
+}
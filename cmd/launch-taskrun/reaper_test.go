@@ -0,0 +1,104 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"go.uber.org/zap/zaptest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/testutil"
+)
+
+func newCompletedTaskRun(name string, labels map[string]string, status corev1.ConditionStatus, completedAgo time.Duration) tektonv1.TaskRun {
+	taskRun := tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace", Labels: labels},
+	}
+	taskRun.Status.Status = duckv1.Status{
+		Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: status}},
+	}
+	if status != corev1.ConditionUnknown {
+		taskRun.Status.CompletionTime = &metav1.Time{Time: time.Now().Add(-completedAgo)}
+	}
+	return taskRun
+}
+
+func TestReapCompletedTaskRuns(t *testing.T) {
+	managed := map[string]string{managedByLabel: managedByValue, instanceLabel: "test-snapshot"}
+
+	taskRuns := []tektonv1.TaskRun{
+		newCompletedTaskRun("old-succeeded", managed, corev1.ConditionTrue, 48*time.Hour),
+		newCompletedTaskRun("old-failed", managed, corev1.ConditionFalse, 48*time.Hour),
+		newCompletedTaskRun("recent-succeeded", managed, corev1.ConditionTrue, time.Hour),
+		newCompletedTaskRun("still-running", managed, corev1.ConditionUnknown, 0),
+		newCompletedTaskRun("not-ours", map[string]string{managedByLabel: "someone-else", instanceLabel: "x"}, corev1.ConditionTrue, 48*time.Hour),
+		newCompletedTaskRun("no-instance", map[string]string{managedByLabel: managedByValue}, corev1.ConditionTrue, 48*time.Hour),
+	}
+	tekton := testutil.NewFakeTekton()
+	for i := range taskRuns {
+		_, err := tekton.TaskRuns("test-namespace").Create(context.Background(), &taskRuns[i], metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	service := NewServiceWithDependencies(&mockK8sClient{}, &fakeTektonClient{fake: tekton}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+
+	deleted, err := service.reapCompletedTaskRuns(context.Background(), "test-namespace", 24*time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	var remaining []string
+	for _, taskRun := range tekton.Created() {
+		remaining = append(remaining, taskRun.Name)
+	}
+	assert.ElementsMatch(t, []string{"recent-succeeded", "still-running", "not-ours", "no-instance"}, remaining)
+}
+
+func TestStartTaskRunReaper_StopsOnClose(t *testing.T) {
+	listed := make(chan struct{}, 1)
+	mockTaskRuns := &mockTektonTaskRunCreator{}
+	mockTaskRuns.On("List", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		select {
+		case listed <- struct{}{}:
+		default:
+		}
+	}).Return(&tektonv1.TaskRunList{}, nil)
+	mockTektonV1 := &mockTektonV1{}
+	mockTektonV1.On("TaskRuns", "test-namespace").Return(mockTaskRuns)
+	mockTekton := &mockTektonClient{}
+	mockTekton.On("TektonV1").Return(mockTektonV1)
+
+	service := NewServiceWithDependencies(&mockK8sClient{}, mockTekton, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	service.startTaskRunReaper("test-namespace", time.Hour, time.Hour)
+
+	// The first sweep happens straight away
+	select {
+	case <-listed:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not run")
+	}
+
+	service.Close()
+}
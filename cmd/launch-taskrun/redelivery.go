@@ -0,0 +1,158 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	gozap "go.uber.org/zap"
+)
+
+const (
+	// deliveryTrackerTTL is how long an event's failed deliveries are
+	// remembered after the last one. Knative's redelivery backoff is well
+	// within it, so an event seen again after that is counted from zero.
+	deliveryTrackerTTL = time.Hour
+	// deliveryTrackerMaxEntries bounds how many events are tracked, the least
+	// recently failed ones are forgotten first
+	deliveryTrackerMaxEntries = 10000
+)
+
+// Extensions set on events sent to the dead letter sink
+const (
+	deadLetterErrorExtension    = "conformaerror"
+	deadLetterAttemptsExtension = "conformadeliveryattempts"
+)
+
+// deliveryTracker counts the failed deliveries of each event, keyed by
+// eventLogKey, so an event that never succeeds can be given up on
+type deliveryTracker struct {
+	mu         sync.Mutex
+	entries    map[string]*trackedDelivery
+	order      *list.List // keys, most recently failed at the front
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type trackedDelivery struct {
+	failures int
+	lastSeen time.Time
+	element  *list.Element
+}
+
+func newDeliveryTracker(ttl time.Duration, maxEntries int) *deliveryTracker {
+	return &deliveryTracker{
+		entries:    make(map[string]*trackedDelivery),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// recordFailure counts a failed delivery, returning how many deliveries of
+// the event have failed
+func (d *deliveryTracker) recordFailure(key string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if tracked, exists := d.entries[key]; exists {
+		if now.Sub(tracked.lastSeen) < d.ttl {
+			tracked.failures++
+			tracked.lastSeen = now
+			d.order.MoveToFront(tracked.element)
+			return tracked.failures
+		}
+		d.remove(key)
+	}
+
+	d.entries[key] = &trackedDelivery{failures: 1, lastSeen: now, element: d.order.PushFront(key)}
+	for d.maxEntries > 0 && len(d.entries) > d.maxEntries {
+		d.remove(d.order.Back().Value.(string))
+	}
+	return 1
+}
+
+// forget stops tracking an event, once it's been handled or given up on
+func (d *deliveryTracker) forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.remove(key)
+}
+
+// remove deletes an entry, the caller must hold the lock
+func (d *deliveryTracker) remove(key string) {
+	if tracked, exists := d.entries[key]; exists {
+		d.order.Remove(tracked.element)
+		delete(d.entries, key)
+	}
+}
+
+// trackDelivery gives up on events that keep failing. With MAX_REDELIVERIES
+// set, an event whose delivery fails after that many redeliveries is sent to
+// the dead letter sink, if there is one, and acknowledged so it's no longer
+// redelivered.
+func (s *Service) trackDelivery(ctx context.Context, event cloudevents.Event, result eventResult, err error) (eventResult, error) {
+	if s.deliveries == nil {
+		return result, err
+	}
+	key := eventLogKey(event)
+	if result != resultFailed {
+		s.deliveries.forget(key)
+		return result, err
+	}
+
+	attempts := s.deliveries.recordFailure(key)
+	if attempts <= s.maxRedeliveries {
+		return result, err
+	}
+
+	s.deliveries.forget(key)
+	s.deadLetter(ctx, event, attempts, resultError(result, err))
+	if s.eventLog != nil {
+		// A copy of the event still being processed keeps its entry
+		if removeErr := s.eventLog.discard(key); removeErr != nil {
+			s.logger.Error(removeErr, "Failed to remove event from event log", gozap.String("id", event.ID()))
+		}
+	}
+	return resultDeadLettered, nil
+}
+
+// deadLetter records an event that's been given up on. The event has already
+// failed, so failing to send it on is only logged.
+func (s *Service) deadLetter(ctx context.Context, event cloudevents.Event, attempts int, err error) {
+	s.logger.Error(err, "Giving up on event after too many failed deliveries",
+		gozap.String("id", event.ID()),
+		gozap.String("source", event.Source()),
+		gozap.Int("attempts", attempts))
+	if s.deadLetterSender == nil {
+		return
+	}
+
+	deadLettered := event.Clone()
+	deadLettered.SetExtension(deadLetterErrorExtension, sanitizeAnnotationValue(err.Error()))
+	deadLettered.SetExtension(deadLetterAttemptsExtension, attempts)
+	if sendErr := s.deadLetterSender.Send(ctx, deadLettered); sendErr != nil {
+		s.logger.Error(sendErr, "Failed to send event to the dead letter sink", gozap.String("id", event.ID()))
+	}
+}
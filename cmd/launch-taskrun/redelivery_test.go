@@ -0,0 +1,113 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newPoisonEvent returns an event whose data can't be parsed, so handling it
+// always fails
+func newPoisonEvent(t *testing.T, id string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetSource("test-source")
+	event.SetType(apiServerAddEventType)
+	require.NoError(t, event.SetData(cloudevents.ApplicationJSON, []byte(`{"kind":`)))
+	return event
+}
+
+func TestDeliveryTracker(t *testing.T) {
+	tracker := newDeliveryTracker(time.Minute, 2)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	assert.Equal(t, 1, tracker.recordFailure("a"))
+	assert.Equal(t, 2, tracker.recordFailure("a"))
+
+	// Forgotten once the event succeeds
+	tracker.forget("a")
+	assert.Equal(t, 1, tracker.recordFailure("a"))
+
+	// Counted from zero once the TTL has passed
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, tracker.recordFailure("a"))
+
+	// The least recently failed event is evicted when full
+	tracker.recordFailure("b")
+	tracker.recordFailure("a")
+	tracker.recordFailure("c")
+	assert.Equal(t, 3, tracker.recordFailure("a"))
+	assert.Equal(t, 1, tracker.recordFailure("b"))
+}
+
+func TestHandleCloudEvent_MaxRedeliveries(t *testing.T) {
+	sender := &mockEventSender{}
+	sender.On("Send", mock.Anything, mock.Anything).Return(nil)
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{
+		MaxRedeliveries:  2,
+		DeadLetterSender: sender,
+	})
+	event := newPoisonEvent(t, "poison")
+
+	// The first delivery and redelivery fail and are redelivered
+	for range 2 {
+		assert.ErrorContains(t, service.handleCloudEvent(context.Background(), event), "failed to parse event data")
+	}
+	sender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+
+	// The second redelivery is acknowledged and dead lettered
+	assert.NoError(t, service.handleCloudEvent(context.Background(), event))
+	require.Len(t, sender.Calls, 1)
+	deadLettered := sender.Calls[0].Arguments.Get(1).(cloudevents.Event)
+	assert.Equal(t, "poison", deadLettered.ID())
+	assert.Equal(t, event.Data(), deadLettered.Data())
+	assert.Contains(t, deadLettered.Extensions()[deadLetterErrorExtension], "failed to parse event data")
+	assert.EqualValues(t, 3, deadLettered.Extensions()[deadLetterAttemptsExtension])
+
+	// Other events are tracked separately, and a redelivery of the dead
+	// lettered event starts counting again
+	assert.Error(t, service.handleCloudEvent(context.Background(), newPoisonEvent(t, "other")))
+	assert.Error(t, service.handleCloudEvent(context.Background(), event))
+}
+
+func TestHandleCloudEvent_SuccessResetsRedeliveries(t *testing.T) {
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{MaxRedeliveries: 1})
+
+	assert.Error(t, service.handleCloudEvent(context.Background(), newPoisonEvent(t, "flaky")))
+	// The same id handled successfully, as if the failure was transient
+	assert.NoError(t, service.handleCloudEvent(context.Background(), newIgnoredEvent(t, "flaky")))
+	assert.Error(t, service.handleCloudEvent(context.Background(), newPoisonEvent(t, "flaky")))
+	assert.NoError(t, service.handleCloudEvent(context.Background(), newPoisonEvent(t, "flaky")))
+}
+
+func TestHandleCloudEvent_RedeliveriesUnlimitedByDefault(t *testing.T) {
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	event := newPoisonEvent(t, "poison")
+
+	for range 10 {
+		assert.Error(t, service.handleCloudEvent(context.Background(), event))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package retry retries operations that fail with transient errors, backing
+// off between attempts
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Options configures Do. The zero value makes a single attempt.
+type Options struct {
+	// Attempts is the most times the operation is tried, values below one
+	// mean one
+	Attempts int
+	// BaseDelay is the delay before the first retry. It doubles for each
+	// retry after that, up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, before jitter is added. Zero
+	// means no cap, setting it to BaseDelay gives a fixed delay.
+	MaxDelay time.Duration
+	// Jitter adds up to this fraction of the delay, chosen at random, so
+	// that clients failing together don't all retry together
+	Jitter float64
+	// Retryable reports whether an error is worth retrying, nil retries
+	// every error
+	Retryable func(err error) bool
+	// AdjustDelay optionally changes the delay before retrying after err,
+	// such as to wait for as long as the server asked
+	AdjustDelay func(err error, delay time.Duration) time.Duration
+	// OnRetry is optionally called after an attempt fails with a retryable
+	// error and before waiting for delay to retry it
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// randFloat returns a random number in [0, 1), replaced in tests
+var randFloat = rand.Float64
+
+// Do calls fn until it succeeds, fails with an error that isn't retryable,
+// or has been tried opts.Attempts times, returning its last error. If ctx is
+// done after fn fails, fn's error is returned without retrying. If ctx is
+// done while waiting to retry, ctx.Err() is returned.
+func Do(ctx context.Context, opts Options, fn func() error) error {
+	attempts := max(opts.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || attempt >= attempts {
+			return err
+		}
+		if opts.Retryable != nil && !opts.Retryable(err) {
+			return err
+		}
+
+		delay := opts.delay(attempt, err)
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns how long to wait after the given attempt failed with err
+func (opts Options) delay(attempt int, err error) time.Duration {
+	delay := opts.BaseDelay
+	for i := 1; i < attempt && delay < math.MaxInt64/2 && (opts.MaxDelay == 0 || delay < opts.MaxDelay); i++ {
+		delay *= 2
+	}
+	if opts.MaxDelay > 0 {
+		delay = min(delay, opts.MaxDelay)
+	}
+	if opts.Jitter > 0 {
+		delay += time.Duration(opts.Jitter * randFloat() * float64(delay))
+	}
+	if opts.AdjustDelay != nil {
+		delay = opts.AdjustDelay(err, delay)
+	}
+	return delay
+}
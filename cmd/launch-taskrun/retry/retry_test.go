@@ -0,0 +1,170 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errTransient = errors.New("transient")
+	errPermanent = errors.New("permanent")
+)
+
+// failing returns an operation that fails with the given errors in turn and
+// then succeeds, and a pointer to how many times it was called
+func failing(errs ...error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+func TestDo(t *testing.T) {
+	isTransient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		name     string
+		opts     Options
+		errs     []error
+		expected error
+		calls    int
+	}{
+		{name: "succeeds first time", opts: Options{Attempts: 3}, calls: 1},
+		{name: "succeeds after retries", opts: Options{Attempts: 3}, errs: []error{errTransient, errTransient}, calls: 3},
+		{name: "gives up after attempts", opts: Options{Attempts: 2}, errs: []error{errTransient, errPermanent, errTransient}, expected: errPermanent, calls: 2},
+		{name: "zero attempts tries once", opts: Options{}, errs: []error{errTransient}, expected: errTransient, calls: 1},
+		{name: "negative attempts tries once", opts: Options{Attempts: -1}, errs: []error{errTransient}, expected: errTransient, calls: 1},
+		{name: "nil classifier retries everything", opts: Options{Attempts: 3}, errs: []error{errPermanent}, calls: 2},
+		{name: "non-retryable error", opts: Options{Attempts: 3, Retryable: isTransient}, errs: []error{errTransient, errPermanent}, expected: errPermanent, calls: 2},
+		{name: "retryable error", opts: Options{Attempts: 3, Retryable: isTransient}, errs: []error{errTransient}, calls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, calls := failing(tt.errs...)
+
+			err := Do(context.Background(), tt.opts, fn)
+
+			assert.Equal(t, tt.expected, err)
+			assert.Equal(t, tt.calls, *calls)
+		})
+	}
+}
+
+func TestDo_OnRetry(t *testing.T) {
+	type retried struct {
+		attempt int
+		err     error
+		delay   time.Duration
+	}
+	var got []retried
+	fn, _ := failing(errTransient, errTransient, errTransient)
+
+	err := Do(context.Background(), Options{
+		Attempts:  3,
+		BaseDelay: time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			got = append(got, retried{attempt, err, delay})
+		},
+	}, fn)
+
+	assert.Equal(t, errTransient, err)
+	// Not called after the last attempt, as there's no retry
+	assert.Equal(t, []retried{
+		{1, errTransient, time.Millisecond},
+		{2, errTransient, 2 * time.Millisecond},
+	}, got)
+}
+
+func TestDo_Context(t *testing.T) {
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		fn, calls := failing(errTransient, errTransient)
+
+		start := time.Now()
+		err := Do(ctx, Options{
+			Attempts:  3,
+			BaseDelay: time.Hour,
+			OnRetry:   func(int, error, time.Duration) { cancel() },
+		}, fn)
+
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 1, *calls)
+		assert.Less(t, time.Since(start), time.Minute)
+	})
+
+	t.Run("done when the operation fails", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+
+		err := Do(ctx, Options{Attempts: 3}, func() error {
+			calls++
+			cancel()
+			return errTransient
+		})
+
+		assert.Equal(t, errTransient, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestOptions_Delay(t *testing.T) {
+	defer func(orig func() float64) { randFloat = orig }(randFloat)
+	randFloat = func() float64 { return 0.5 }
+
+	tests := []struct {
+		name     string
+		opts     Options
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "first retry", opts: Options{BaseDelay: time.Second}, attempt: 1, expected: time.Second},
+		{name: "doubles", opts: Options{BaseDelay: time.Second}, attempt: 4, expected: 8 * time.Second},
+		{name: "capped", opts: Options{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, attempt: 4, expected: 5 * time.Second},
+		{name: "fixed", opts: Options{BaseDelay: time.Second, MaxDelay: time.Second}, attempt: 4, expected: time.Second},
+		{name: "jitter", opts: Options{BaseDelay: time.Second, Jitter: 0.2}, attempt: 1, expected: 1100 * time.Millisecond},
+		{name: "jitter after cap", opts: Options{BaseDelay: time.Second, MaxDelay: 2 * time.Second, Jitter: 0.5}, attempt: 3, expected: 2500 * time.Millisecond},
+		{
+			name: "adjusted",
+			opts: Options{BaseDelay: time.Second, AdjustDelay: func(err error, delay time.Duration) time.Duration {
+				return delay + time.Minute
+			}},
+			attempt:  1,
+			expected: time.Minute + time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.opts.delay(tt.attempt, errTransient))
+		})
+	}
+
+	t.Run("no overflow", func(t *testing.T) {
+		assert.Positive(t, Options{BaseDelay: time.Second}.delay(100, errTransient))
+	})
+}
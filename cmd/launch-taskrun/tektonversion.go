@@ -0,0 +1,180 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	tektontypedv1beta1 "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/typed/pipeline/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// TektonAPIVersion is the tekton.dev API version TaskRuns are created with.
+// The service builds v1 TaskRuns either way; with v1beta1 they're converted
+// on the way to and from the cluster, for clusters running an older Tekton.
+type TektonAPIVersion string
+
+const (
+	// TektonAPIVersionAuto uses v1 if the cluster serves it, otherwise v1beta1
+	TektonAPIVersionAuto    TektonAPIVersion = "auto"
+	TektonAPIVersionV1      TektonAPIVersion = "v1"
+	TektonAPIVersionV1beta1 TektonAPIVersion = "v1beta1"
+)
+
+// parseTektonAPIVersion reads TEKTON_API_VERSION, defaulting to auto
+func parseTektonAPIVersion(val string) (TektonAPIVersion, error) {
+	switch version := TektonAPIVersion(strings.ToLower(strings.TrimSpace(val))); version {
+	case "":
+		return TektonAPIVersionAuto, nil
+	case TektonAPIVersionAuto, TektonAPIVersionV1, TektonAPIVersionV1beta1:
+		return version, nil
+	default:
+		return "", fmt.Errorf("TEKTON_API_VERSION %q is not supported, must be auto, v1 or v1beta1", val)
+	}
+}
+
+// apiResourceDiscoverer is the part of the discovery client used to find
+// which Tekton API versions the cluster serves
+type apiResourceDiscoverer interface {
+	ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error)
+}
+
+// resolveTektonAPIVersion turns auto into the newest version the cluster
+// serves TaskRuns with. Explicit versions are returned as they are.
+func resolveTektonAPIVersion(discovery apiResourceDiscoverer, version TektonAPIVersion) (TektonAPIVersion, error) {
+	if version != TektonAPIVersionAuto {
+		return version, nil
+	}
+	for _, candidate := range []TektonAPIVersion{TektonAPIVersionV1, TektonAPIVersionV1beta1} {
+		served, err := servesTaskRuns(discovery, candidate)
+		if err != nil {
+			return "", err
+		}
+		if served {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("the cluster doesn't serve TaskRuns with tekton.dev/v1 or tekton.dev/v1beta1")
+}
+
+func servesTaskRuns(discovery apiResourceDiscoverer, version TektonAPIVersion) (bool, error) {
+	groupVersion := "tekton.dev/" + string(version)
+	resources, err := discovery.ServerResourcesForGroupVersion(groupVersion)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to discover %s resources: %w", groupVersion, err)
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "taskruns" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type realTektonV1beta1 struct {
+	client tektontypedv1beta1.TektonV1beta1Interface
+}
+
+func (r *realTektonV1beta1) TaskRuns(ns string) TektonTaskRunCreator {
+	return &v1beta1TaskRunCreator{client: r.client.TaskRuns(ns)}
+}
+
+// v1beta1TaskRunCreator stores TaskRuns with the v1beta1 API, converting
+// them from and to v1 so the rest of the service only deals with v1
+type v1beta1TaskRunCreator struct {
+	client tektontypedv1beta1.TaskRunInterface
+}
+
+func (r *v1beta1TaskRunCreator) Create(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.CreateOptions) (*tektonv1.TaskRun, error) {
+	converted, err := toV1beta1TaskRun(ctx, taskRun)
+	if err != nil {
+		return nil, err
+	}
+	created, err := r.client.Create(ctx, converted, opts)
+	if err != nil {
+		return nil, err
+	}
+	return fromV1beta1TaskRun(ctx, created)
+}
+
+func (r *v1beta1TaskRunCreator) Apply(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.ApplyOptions) (*tektonv1.TaskRun, error) {
+	converted, err := toV1beta1TaskRun(ctx, taskRun)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(converted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode TaskRun %s: %w", taskRun.Name, err)
+	}
+	applied, err := r.client.Patch(ctx, converted.Name, types.ApplyPatchType, data, opts.ToPatchOptions())
+	if err != nil {
+		return nil, err
+	}
+	return fromV1beta1TaskRun(ctx, applied)
+}
+
+func (r *v1beta1TaskRunCreator) List(ctx context.Context, opts metav1.ListOptions) (*tektonv1.TaskRunList, error) {
+	list, err := r.client.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	converted := &tektonv1.TaskRunList{ListMeta: list.ListMeta, Items: make([]tektonv1.TaskRun, 0, len(list.Items))}
+	for i := range list.Items {
+		taskRun, err := fromV1beta1TaskRun(ctx, &list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		converted.Items = append(converted.Items, *taskRun)
+	}
+	return converted, nil
+}
+
+func (r *v1beta1TaskRunCreator) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return r.client.Delete(ctx, name, opts)
+}
+
+// toV1beta1TaskRun converts a v1 TaskRun using Tekton's own conversion, the
+// same one its conversion webhook uses
+func toV1beta1TaskRun(ctx context.Context, taskRun *tektonv1.TaskRun) (*tektonv1beta1.TaskRun, error) {
+	converted := &tektonv1beta1.TaskRun{}
+	if err := converted.ConvertFrom(ctx, taskRun.DeepCopy()); err != nil {
+		return nil, fmt.Errorf("failed to convert TaskRun %s to v1beta1: %w", taskRun.Name, err)
+	}
+	converted.APIVersion = tektonv1beta1.SchemeGroupVersion.String()
+	converted.Kind = "TaskRun"
+	return converted, nil
+}
+
+func fromV1beta1TaskRun(ctx context.Context, taskRun *tektonv1beta1.TaskRun) (*tektonv1.TaskRun, error) {
+	converted := &tektonv1.TaskRun{}
+	if err := taskRun.DeepCopy().ConvertTo(ctx, converted); err != nil {
+		return nil, fmt.Errorf("failed to convert TaskRun %s from v1beta1: %w", taskRun.Name, err)
+	}
+	converted.APIVersion = tektonv1.SchemeGroupVersion.String()
+	converted.Kind = "TaskRun"
+	return converted, nil
+}
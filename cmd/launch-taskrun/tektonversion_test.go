@@ -0,0 +1,263 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	tektonv1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestParseTektonAPIVersion(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected TektonAPIVersion
+		wantErr  bool
+	}{
+		{value: "", expected: TektonAPIVersionAuto},
+		{value: "auto", expected: TektonAPIVersionAuto},
+		{value: "v1", expected: TektonAPIVersionV1},
+		{value: " V1beta1 ", expected: TektonAPIVersionV1beta1},
+		{value: "v1alpha1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			version, err := parseTektonAPIVersion(tt.value)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "TEKTON_API_VERSION")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+// fakeDiscovery serves TaskRuns for the listed group versions
+type fakeDiscovery struct {
+	served []string
+	err    error
+}
+
+func (f *fakeDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, served := range f.served {
+		if served == groupVersion {
+			return &metav1.APIResourceList{
+				GroupVersion: groupVersion,
+				APIResources: []metav1.APIResource{{Name: "tasks"}, {Name: "taskruns"}},
+			}, nil
+		}
+	}
+	gv, _ := schema.ParseGroupVersion(groupVersion)
+	return nil, apierrors.NewNotFound(gv.WithResource("").GroupResource(), "")
+}
+
+func TestResolveTektonAPIVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested TektonAPIVersion
+		discovery *fakeDiscovery
+		expected  TektonAPIVersion
+		wantErr   string
+	}{
+		{
+			name:      "auto prefers v1",
+			requested: TektonAPIVersionAuto,
+			discovery: &fakeDiscovery{served: []string{"tekton.dev/v1beta1", "tekton.dev/v1"}},
+			expected:  TektonAPIVersionV1,
+		},
+		{
+			name:      "auto falls back to v1beta1",
+			requested: TektonAPIVersionAuto,
+			discovery: &fakeDiscovery{served: []string{"tekton.dev/v1beta1"}},
+			expected:  TektonAPIVersionV1beta1,
+		},
+		{
+			name:      "auto with no Tekton",
+			requested: TektonAPIVersionAuto,
+			discovery: &fakeDiscovery{},
+			wantErr:   "doesn't serve TaskRuns",
+		},
+		{
+			name:      "auto with discovery failure",
+			requested: TektonAPIVersionAuto,
+			discovery: &fakeDiscovery{err: errors.New("connection refused")},
+			wantErr:   "connection refused",
+		},
+		{
+			name:      "explicit version skips discovery",
+			requested: TektonAPIVersionV1beta1,
+			discovery: &fakeDiscovery{err: errors.New("should not be called")},
+			expected:  TektonAPIVersionV1beta1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := resolveTektonAPIVersion(tt.discovery, tt.requested)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func newVersionTestTaskRun() *tektonv1.TaskRun {
+	return &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verify-conforma-test-snapshot-1",
+			Namespace: "test-namespace",
+			Labels:    map[string]string{managedByLabel: managedByValue, instanceLabel: "test-snapshot"},
+		},
+		Spec: tektonv1.TaskRunSpec{
+			TaskRef: &tektonv1.TaskRef{
+				ResolverRef: tektonv1.ResolverRef{
+					Resolver: "cluster",
+					Params: tektonv1.Params{
+						{Name: "kind", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: "task"}},
+						{Name: "name", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: "generate-vsa"}},
+					},
+				},
+			},
+			Params: tektonv1.Params{
+				{Name: "POLICY_CONFIGURATION", Value: tektonv1.ParamValue{Type: tektonv1.ParamTypeString, StringVal: "ns/policy"}},
+			},
+			Workspaces: []tektonv1.WorkspaceBinding{{Name: signingKeyWorkspace}},
+		},
+	}
+}
+
+func TestRealTektonClient_V1(t *testing.T) {
+	clientset := tektonfake.NewSimpleClientset()
+	taskRuns := (&realTektonV1{client: clientset.TektonV1()}).TaskRuns("test-namespace")
+
+	created, err := taskRuns.Create(context.Background(), newVersionTestTaskRun(), metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "verify-conforma-test-snapshot-1", created.Name)
+
+	stored, err := clientset.TektonV1().TaskRuns("test-namespace").Get(context.Background(), created.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "cluster", string(stored.Spec.TaskRef.Resolver))
+
+	_, err = clientset.TektonV1beta1().TaskRuns("test-namespace").Get(context.Background(), created.Name, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestRealTektonClient_V1beta1(t *testing.T) {
+	clientset := tektonfake.NewSimpleClientset()
+	taskRuns := (&realTektonV1beta1{client: clientset.TektonV1beta1()}).TaskRuns("test-namespace")
+	taskRun := newVersionTestTaskRun()
+
+	created, err := taskRuns.Create(context.Background(), taskRun, metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, taskRun.Name, created.Name)
+	assert.Equal(t, taskRun.Spec.TaskRef, created.Spec.TaskRef)
+	assert.Equal(t, taskRun.Spec.Params, created.Spec.Params)
+	assert.Equal(t, tektonv1.SchemeGroupVersion.String(), created.APIVersion)
+
+	// The cluster got a v1beta1 TaskRun with the same content
+	stored, err := clientset.TektonV1beta1().TaskRuns("test-namespace").Get(context.Background(), taskRun.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "cluster", string(stored.Spec.TaskRef.Resolver))
+	assert.Equal(t, "ns/policy", stored.Spec.Params[0].Value.StringVal)
+	assert.Equal(t, taskRun.Labels, stored.Labels)
+
+	list, err := taskRuns.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, taskRun.Spec.Params, list.Items[0].Spec.Params)
+
+	require.NoError(t, taskRuns.Delete(context.Background(), taskRun.Name, metav1.DeleteOptions{}))
+	list, err = taskRuns.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+}
+
+func TestRealTektonClient_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		taskRuns   func(clientset *tektonfake.Clientset) TektonTaskRunCreator
+		apiVersion string
+	}{
+		{
+			name: "v1",
+			taskRuns: func(clientset *tektonfake.Clientset) TektonTaskRunCreator {
+				return (&realTektonV1{client: clientset.TektonV1()}).TaskRuns("test-namespace")
+			},
+			apiVersion: "tekton.dev/v1",
+		},
+		{
+			name: "v1beta1",
+			taskRuns: func(clientset *tektonfake.Clientset) TektonTaskRunCreator {
+				return (&realTektonV1beta1{client: clientset.TektonV1beta1()}).TaskRuns("test-namespace")
+			},
+			apiVersion: "tekton.dev/v1beta1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := tektonfake.NewSimpleClientset()
+			var patch k8stesting.PatchAction
+			clientset.PrependReactor("patch", "taskruns", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patch = action.(k8stesting.PatchAction)
+				// The fake clientset can't apply, so the object sent is
+				// echoed back as the applied one
+				var applied runtime.Object = &tektonv1.TaskRun{}
+				if tt.apiVersion == "tekton.dev/v1beta1" {
+					applied = &tektonv1beta1.TaskRun{}
+				}
+				return true, applied, json.Unmarshal(patch.GetPatch(), applied)
+			})
+			taskRun := newVersionTestTaskRun()
+
+			applied, err := tt.taskRuns(clientset).Apply(context.Background(), taskRun, metav1.ApplyOptions{FieldManager: managedByValue, Force: true})
+
+			require.NoError(t, err)
+			require.NotNil(t, patch)
+			assert.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+			assert.Equal(t, taskRun.Name, patch.GetName())
+			var sent map[string]any
+			require.NoError(t, json.Unmarshal(patch.GetPatch(), &sent))
+			assert.Equal(t, tt.apiVersion, sent["apiVersion"])
+			assert.Equal(t, "TaskRun", sent["kind"])
+			assert.Equal(t, taskRun.Name, applied.Name)
+			assert.Equal(t, taskRun.Spec.Params, applied.Spec.Params)
+			assert.Empty(t, taskRun.APIVersion, "the TaskRun passed in isn't changed")
+		})
+	}
+}
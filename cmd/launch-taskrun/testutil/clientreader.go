@@ -0,0 +1,123 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Response is what a FakeClientReader call returns: Err if it's set,
+// otherwise a copy of Object
+type Response struct {
+	Object runtime.Object
+	Err    error
+}
+
+// FakeClientReader implements the Get and List methods of a controller-runtime
+// client, which is all konflux.ClientReader needs. Each call returns the next
+// Response queued for it, so tests can simulate transient errors and
+// conflicts. Once the queue is empty, calls go to Fallback, typically a
+// controller-runtime fake client, or fail if there's none.
+type FakeClientReader struct {
+	mu    sync.Mutex
+	gets  []Response
+	lists []Response
+	// Fallback, if set, serves the calls without a queued Response
+	Fallback interface {
+		Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error
+		List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error
+	}
+	// GetCalls and ListCalls count the calls made, queued or not
+	GetCalls  int
+	ListCalls int
+}
+
+// QueueGet queues responses for the following Get calls
+func (f *FakeClientReader) QueueGet(responses ...Response) *FakeClientReader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets = append(f.gets, responses...)
+	return f
+}
+
+// QueueList queues responses for the following List calls
+func (f *FakeClientReader) QueueList(responses ...Response) *FakeClientReader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists = append(f.lists, responses...)
+	return f
+}
+
+func (f *FakeClientReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	f.mu.Lock()
+	f.GetCalls++
+	response, ok := pop(&f.gets)
+	f.mu.Unlock()
+	if ok {
+		return response.into(obj)
+	}
+	if f.Fallback != nil {
+		return f.Fallback.Get(ctx, key, obj, opts...)
+	}
+	return fmt.Errorf("unexpected Get of %T %s", obj, key)
+}
+
+func (f *FakeClientReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	f.mu.Lock()
+	f.ListCalls++
+	response, ok := pop(&f.lists)
+	f.mu.Unlock()
+	if ok {
+		return response.into(list)
+	}
+	if f.Fallback != nil {
+		return f.Fallback.List(ctx, list, opts...)
+	}
+	return fmt.Errorf("unexpected List of %T", list)
+}
+
+func pop(queue *[]Response) (Response, bool) {
+	if len(*queue) == 0 {
+		return Response{}, false
+	}
+	response := (*queue)[0]
+	*queue = (*queue)[1:]
+	return response, true
+}
+
+// into returns the response's error, or copies its object into out
+func (r Response) into(out runtime.Object) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.Object == nil {
+		return nil
+	}
+	src := reflect.ValueOf(r.Object.DeepCopyObject())
+	dst := reflect.ValueOf(out)
+	if src.Type() != dst.Type() {
+		return fmt.Errorf("queued %T can't be returned as %T", r.Object, out)
+	}
+	dst.Elem().Set(src.Elem())
+	return nil
+}
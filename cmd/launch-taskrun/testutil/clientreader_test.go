@@ -0,0 +1,70 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFakeClientReader(t *testing.T) {
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: "test-ns", Name: "cm"}
+	queued := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "cm"}, Data: map[string]string{"from": "queue"}}
+	stored := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "cm"}, Data: map[string]string{"from": "fallback"}}
+
+	reader := (&FakeClientReader{Fallback: fake.NewClientBuilder().WithObjects(stored).Build()}).
+		QueueGet(Response{Err: apierrors.NewServiceUnavailable("try again")}, Response{Object: queued}).
+		QueueList(Response{Err: apierrors.NewConflict(corev1.Resource("configmaps"), "cm", nil)})
+
+	var cm corev1.ConfigMap
+	assert.True(t, apierrors.IsServiceUnavailable(reader.Get(ctx, key, &cm)))
+	require.NoError(t, reader.Get(ctx, key, &cm))
+	assert.Equal(t, "queue", cm.Data["from"])
+	// Returned objects are copies
+	cm.Data["from"] = "changed"
+	assert.Equal(t, "queue", queued.Data["from"])
+	// With the queue drained the fallback is used
+	require.NoError(t, reader.Get(ctx, key, &cm))
+	assert.Equal(t, "fallback", cm.Data["from"])
+
+	var list corev1.ConfigMapList
+	assert.True(t, apierrors.IsConflict(reader.List(ctx, &list)))
+	require.NoError(t, reader.List(ctx, &list))
+	assert.Len(t, list.Items, 1)
+
+	assert.Equal(t, 3, reader.GetCalls)
+	assert.Equal(t, 2, reader.ListCalls)
+}
+
+func TestFakeClientReader_Unexpected(t *testing.T) {
+	ctx := context.Background()
+	reader := (&FakeClientReader{}).QueueGet(Response{Object: &corev1.Secret{}})
+
+	var cm corev1.ConfigMap
+	assert.ErrorContains(t, reader.Get(ctx, client.ObjectKey{Name: "cm"}, &cm), "queued *v1.Secret can't be returned as *v1.ConfigMap")
+	assert.ErrorContains(t, reader.Get(ctx, client.ObjectKey{Name: "cm"}, &cm), "unexpected Get")
+	assert.ErrorContains(t, reader.List(ctx, &corev1.ConfigMapList{}), "unexpected List")
+}
@@ -0,0 +1,178 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package testutil holds fakes shared by the service's unit tests
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var taskRunResource = schema.GroupResource{Group: "tekton.dev", Resource: "taskruns"}
+
+// FakeTekton is an in-memory store of TaskRuns. Its TaskRuns method returns a
+// client implementing the service's TektonTaskRunCreator interface, so tests
+// can exercise the service and then inspect exactly what it created.
+type FakeTekton struct {
+	mu       sync.Mutex
+	taskRuns map[string]*tektonv1.TaskRun
+	// generated counts names made from GenerateName
+	generated int
+	// CreateError, if set, is returned by every Create instead of storing
+	// the TaskRun
+	CreateError error
+}
+
+func NewFakeTekton(taskRuns ...*tektonv1.TaskRun) *FakeTekton {
+	f := &FakeTekton{taskRuns: map[string]*tektonv1.TaskRun{}}
+	for _, tr := range taskRuns {
+		f.taskRuns[key(tr.Namespace, tr.Name)] = tr.DeepCopy()
+	}
+	return f
+}
+
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// TaskRuns returns a client for the TaskRuns in the given namespace
+func (f *FakeTekton) TaskRuns(namespace string) *FakeTaskRuns {
+	return &FakeTaskRuns{fake: f, namespace: namespace}
+}
+
+// Get returns a copy of the stored TaskRun, or nil if there isn't one
+func (f *FakeTekton) Get(namespace, name string) *tektonv1.TaskRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tr, ok := f.taskRuns[key(namespace, name)]
+	if !ok {
+		return nil
+	}
+	return tr.DeepCopy()
+}
+
+// Created returns copies of all stored TaskRuns, sorted by namespace and name
+func (f *FakeTekton) Created() []*tektonv1.TaskRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.taskRuns))
+	for k := range f.taskRuns {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*tektonv1.TaskRun, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, f.taskRuns[k].DeepCopy())
+	}
+	return out
+}
+
+// FakeTaskRuns is the namespaced TaskRun client returned by FakeTekton.TaskRuns
+type FakeTaskRuns struct {
+	fake      *FakeTekton
+	namespace string
+}
+
+func (c *FakeTaskRuns) Create(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.CreateOptions) (*tektonv1.TaskRun, error) {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	if c.fake.CreateError != nil {
+		return nil, c.fake.CreateError
+	}
+
+	tr := taskRun.DeepCopy()
+	tr.Namespace = c.namespace
+	if tr.Name == "" {
+		if tr.GenerateName == "" {
+			return nil, apierrors.NewBadRequest("name or generateName is required")
+		}
+		// A counter keeps generated names predictable in tests, skipping
+		// any already taken
+		for tr.Name == "" || c.fake.taskRuns[key(tr.Namespace, tr.Name)] != nil {
+			c.fake.generated++
+			tr.Name = fmt.Sprintf("%s%d", tr.GenerateName, c.fake.generated)
+		}
+	}
+	k := key(tr.Namespace, tr.Name)
+	if _, exists := c.fake.taskRuns[k]; exists {
+		return nil, apierrors.NewAlreadyExists(taskRunResource, tr.Name)
+	}
+	c.fake.taskRuns[k] = tr
+	return tr.DeepCopy(), nil
+}
+
+// Apply stores the TaskRun, or replaces the labels, annotations and spec of
+// the stored one, as a forced server-side apply by a single field manager
+// would
+func (c *FakeTaskRuns) Apply(ctx context.Context, taskRun *tektonv1.TaskRun, opts metav1.ApplyOptions) (*tektonv1.TaskRun, error) {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	if taskRun.Name == "" {
+		return nil, apierrors.NewBadRequest("name is required for apply")
+	}
+	if opts.FieldManager == "" {
+		return nil, apierrors.NewBadRequest("fieldManager is required for apply")
+	}
+
+	k := key(c.namespace, taskRun.Name)
+	tr, exists := c.fake.taskRuns[k]
+	if !exists {
+		tr = taskRun.DeepCopy()
+		tr.Namespace = c.namespace
+		c.fake.taskRuns[k] = tr
+		return tr.DeepCopy(), nil
+	}
+	applied := taskRun.DeepCopy()
+	tr.Labels = applied.Labels
+	tr.Annotations = applied.Annotations
+	tr.Spec = applied.Spec
+	return tr.DeepCopy(), nil
+}
+
+func (c *FakeTaskRuns) List(ctx context.Context, opts metav1.ListOptions) (*tektonv1.TaskRunList, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+
+	list := &tektonv1.TaskRunList{}
+	for _, tr := range c.fake.Created() {
+		if tr.Namespace == c.namespace && selector.Matches(labels.Set(tr.Labels)) {
+			list.Items = append(list.Items, *tr)
+		}
+	}
+	return list, nil
+}
+
+func (c *FakeTaskRuns) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	k := key(c.namespace, name)
+	if _, exists := c.fake.taskRuns[k]; !exists {
+		return apierrors.NewNotFound(taskRunResource, name)
+	}
+	delete(c.fake.taskRuns, k)
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tektonv1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFakeTekton(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeTekton()
+	taskRuns := fake.TaskRuns("test-ns")
+
+	created, err := taskRuns.Create(ctx, &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "tr-1", Labels: map[string]string{"app": "a"}},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "test-ns", created.Namespace)
+
+	generated, err := taskRuns.Create(ctx, &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "tr-", Labels: map[string]string{"app": "b"}},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	// tr-1 is taken so the next generated name is used
+	assert.Equal(t, "tr-2", generated.Name)
+
+	_, err = taskRuns.Create(ctx, &tektonv1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "tr-1"}}, metav1.CreateOptions{})
+	assert.True(t, apierrors.IsAlreadyExists(err))
+
+	// Returned TaskRuns are copies
+	created.Labels["app"] = "changed"
+	assert.Equal(t, "a", fake.Get("test-ns", "tr-1").Labels["app"])
+
+	list, err := taskRuns.List(ctx, metav1.ListOptions{LabelSelector: "app=b"})
+	assert.NoError(t, err)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, generated.Name, list.Items[0].Name)
+	}
+
+	list, err = fake.TaskRuns("other-ns").List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, list.Items)
+
+	assert.NoError(t, taskRuns.Delete(ctx, "tr-1", metav1.DeleteOptions{}))
+	assert.True(t, apierrors.IsNotFound(taskRuns.Delete(ctx, "tr-1", metav1.DeleteOptions{})))
+	assert.Nil(t, fake.Get("test-ns", "tr-1"))
+	assert.Len(t, fake.Created(), 1)
+}
+
+func TestFakeTekton_CreateError(t *testing.T) {
+	fake := NewFakeTekton()
+	fake.CreateError = errors.New("boom")
+
+	_, err := fake.TaskRuns("test-ns").Create(context.Background(), &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "tr-1"},
+	}, metav1.CreateOptions{})
+
+	assert.EqualError(t, err, "boom")
+	assert.Empty(t, fake.Created())
+}
+
+func TestFakeTekton_Apply(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeTekton()
+	taskRuns := fake.TaskRuns("test-ns")
+	opts := metav1.ApplyOptions{FieldManager: "test", Force: true}
+
+	applied, err := taskRuns.Apply(ctx, &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "tr-1", Labels: map[string]string{"app": "a"}},
+		Spec:       tektonv1.TaskRunSpec{ServiceAccountName: "sa-1"},
+	}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-ns", applied.Namespace)
+
+	applied, err = taskRuns.Apply(ctx, &tektonv1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "tr-1", Labels: map[string]string{"app": "b"}},
+		Spec:       tektonv1.TaskRunSpec{ServiceAccountName: "sa-2"},
+	}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", applied.Labels["app"])
+	assert.Len(t, fake.Created(), 1)
+	assert.Equal(t, "sa-2", fake.Get("test-ns", "tr-1").Spec.ServiceAccountName)
+
+	_, err = taskRuns.Apply(ctx, &tektonv1.TaskRun{ObjectMeta: metav1.ObjectMeta{GenerateName: "tr-"}}, opts)
+	assert.True(t, apierrors.IsBadRequest(err))
+	_, err = taskRuns.Apply(ctx, &tektonv1.TaskRun{ObjectMeta: metav1.ObjectMeta{Name: "tr-1"}}, metav1.ApplyOptions{})
+	assert.True(t, apierrors.IsBadRequest(err))
+}
@@ -0,0 +1,262 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// writeSelfSignedCert writes a self-signed certificate valid for 127.0.0.1
+// and usable for both server and client auth. It returns the cert and key
+// paths along with the parsed key pair.
+func writeSelfSignedCert(t *testing.T) (string, string, tls.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "knative-service-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	return certFile, keyFile, pair
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	certFile, keyFile, _ := writeSelfSignedCert(t)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a certificate"), 0o600))
+
+	t.Run("disabled", func(t *testing.T) {
+		config, err := loadTLSConfig("", "", "")
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("server only", func(t *testing.T) {
+		config, err := loadTLSConfig(certFile, keyFile, "")
+		require.NoError(t, err)
+		assert.Len(t, config.Certificates, 1)
+		assert.Equal(t, tls.NoClientCert, config.ClientAuth)
+		assert.Nil(t, config.ClientCAs)
+	})
+
+	t.Run("client verification", func(t *testing.T) {
+		config, err := loadTLSConfig(certFile, keyFile, certFile)
+		require.NoError(t, err)
+		assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+		assert.NotNil(t, config.ClientCAs)
+	})
+
+	errorCases := []struct {
+		name                      string
+		certFile, keyFile, caFile string
+		expectedErr               string
+	}{
+		{"cert without key", certFile, "", "", "must be set together"},
+		{"key without cert", "", keyFile, "", "must be set together"},
+		{"client CA without cert", "", "", certFile, "requires TLS_CERT_FILE"},
+		{"missing cert", filepath.Join(t.TempDir(), "missing.crt"), keyFile, "", "failed to load TLS certificate"},
+		{"missing client CA", certFile, keyFile, filepath.Join(t.TempDir(), "missing.crt"), "failed to read TLS client CA bundle"},
+		{"invalid client CA", certFile, keyFile, garbage, "no certificates found"},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadTLSConfig(tt.certFile, tt.keyFile, tt.caFile)
+			assert.ErrorContains(t, err, tt.expectedErr)
+			assert.Nil(t, config)
+		})
+	}
+}
+
+// startTLSReceiver starts a CloudEvents receiver serving TLS with the given
+// config and returns its address.
+func startTLSReceiver(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	receiver, err := NewCloudEventsReceiver(ReceiverConfig{
+		Listener:  listener,
+		TLSConfig: tlsConfig,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- receiver.StartReceiver(ctx, func(context.Context, cloudevents.Event) {})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return listener.Addr().String()
+}
+
+// postEvent sends a CloudEvent of an ignored type and returns the response
+// status, or the transport error.
+func postEvent(t *testing.T, client *http.Client, url string) (int, error) {
+	t.Helper()
+
+	event := newSnapshotEvent(t, "1", "test-snapshot")
+	event.SetType("dev.knative.apiserver.resource.update")
+	req, err := cehttp.NewHTTPRequestFromEvent(context.Background(), url, event)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestNewCloudEventsReceiver_TLS(t *testing.T) {
+	certFile, keyFile, pair := writeSelfSignedCert(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(pair.Leaf)
+
+	httpsClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+			},
+		}
+	}
+	plainClient := &http.Client{Timeout: 5 * time.Second}
+
+	t.Run("server TLS", func(t *testing.T) {
+		tlsConfig, err := loadTLSConfig(certFile, keyFile, "")
+		require.NoError(t, err)
+		addr := startTLSReceiver(t, tlsConfig)
+
+		assert.Eventually(t, func() bool {
+			status, err := postEvent(t, httpsClient(), "https://"+addr)
+			return err == nil && status == http.StatusAccepted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Plain HTTP is not served on the TLS port
+		status, err := postEvent(t, plainClient, "http://"+addr)
+		assert.True(t, err != nil || status >= http.StatusBadRequest,
+			"expected plain HTTP request to be rejected, got status %d", status)
+	})
+
+	t.Run("client verification", func(t *testing.T) {
+		tlsConfig, err := loadTLSConfig(certFile, keyFile, certFile)
+		require.NoError(t, err)
+		addr := startTLSReceiver(t, tlsConfig)
+
+		assert.Eventually(t, func() bool {
+			status, err := postEvent(t, httpsClient(pair), "https://"+addr)
+			return err == nil && status == http.StatusAccepted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Clients without a certificate are rejected during the handshake
+		_, err = postEvent(t, httpsClient(), "https://"+addr)
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_Run_RetriesTLSListen(t *testing.T) {
+	certFile, keyFile, pair := writeSelfSignedCert(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(pair.Leaf)
+	tlsConfig, err := loadTLSConfig(certFile, keyFile, "")
+	require.NoError(t, err)
+
+	// Something else holds the port when the receiver is built and started
+	occupied, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	port := occupied.Addr().(*net.TCPAddr).Port
+
+	service := NewServiceWithDependencies(&mockK8sClient{}, &mockTektonClient{}, &mockControllerRuntimeClient{}, &zapLogger{l: zaptest.NewLogger(t)}, ServiceConfig{})
+	receiver, err := newServiceReceiver(service, ReceiverConfig{Port: port, TLSConfig: tlsConfig})
+	require.NoError(t, err, "the port is only bound when the receiver starts")
+	server := NewServer(service, strconv.Itoa(port), receiver)
+	server.listenRetryAttempts = 100
+	server.listenRetryDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, occupied.Close())
+
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+	}
+	assert.Eventually(t, func() bool {
+		status, err := postEvent(t, client, fmt.Sprintf("https://127.0.0.1:%d", port))
+		return err == nil && status == http.StatusAccepted
+	}, 5*time.Second, 10*time.Millisecond)
+}
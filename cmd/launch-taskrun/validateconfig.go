@@ -0,0 +1,277 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/conforma/knative-service/cmd/launch-taskrun/k8s"
+	gozap "go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// boolConfigKeys are the config map keys holding booleans
+var boolConfigKeys = []string{
+	"IGNORE_REKOR",
+	"STRICT",
+	"DEBUG",
+	"MATCH_RELEASEPLAN_BY_TARGET",
+	"PROCESS_EMPTY_SNAPSHOTS",
+	"REUSE_SUCCEEDED_TASKRUNS",
+	"VERIFY_NAMESPACE_EXISTS",
+	"VERIFY_POLICY_EXISTS",
+	"RETRY_ON_MISSING_RELEASEPLAN",
+	"APPLY_RESTRICTED_SECURITY_CONTEXT",
+	"APPLY_TASKRUNS",
+	"ANNOTATE_SNAPSHOT_DISPLAY_INFO",
+}
+
+// intConfigKeys are the config map keys holding positive integers
+var intConfigKeys = []string{
+	"WORKERS",
+	"DEFAULT_WORKERS",
+	"MAX_WORKERS",
+	"CACHE_TTL_MINUTES",
+	"TEKTON_TIMEOUT_SECONDS",
+	"VSA_EXPIRATION_HOURS",
+	"TEKTON_RETRY_ATTEMPTS",
+	"TEKTON_RETRY_DELAY_SECONDS",
+	"K8S_RETRY_ATTEMPTS",
+	"K8S_RETRY_DELAY_SECONDS",
+	"CIRCUIT_BREAKER_THRESHOLD",
+	"CIRCUIT_BREAKER_TIMEOUT_SECONDS",
+	"CIRCUIT_BREAKER_PROBE_INTERVAL_SECONDS",
+	"CIRCUIT_BREAKER_PROBES",
+	"MISSING_RELEASEPLAN_GRACE_SECONDS",
+}
+
+// quantityConfigKeys are the config map keys holding resource quantities
+var quantityConfigKeys = []string{
+	"TASK_CPU_REQUEST",
+	"TASK_MEMORY_REQUEST",
+	"TASK_MEMORY_LIMIT",
+}
+
+// knownConfigKeys returns the config map keys the service reads, taken from
+// the TaskRunConfig field tags
+func knownConfigKeys() []string {
+	var keys []string
+	configType := reflect.TypeOf(TaskRunConfig{})
+	for i := range configType.NumField() {
+		if key := configType.Field(i).Tag.Get("json"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ValidateConfigMap checks config map data the way the service would read it,
+// reporting unknown keys, which are most likely typos, values in the wrong
+// format and settings createTaskRun would refuse. Values the service quietly
+// replaces with a default, like an unparseable boolean, are reported too.
+func ValidateConfigMap(data map[string]string) []error {
+	known := knownConfigKeys()
+
+	var errs []error
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		val := data[key]
+		switch {
+		case !slices.Contains(known, key):
+			if suggestion := closestConfigKey(key, known); suggestion != "" {
+				errs = append(errs, fmt.Errorf("unknown key %s, did you mean %s?", key, suggestion))
+			} else {
+				errs = append(errs, fmt.Errorf("unknown key %s", key))
+			}
+		case strings.TrimSpace(val) == "":
+			// Empty values are treated as unset
+		case slices.Contains(boolConfigKeys, key):
+			switch strings.ToLower(strings.TrimSpace(val)) {
+			case "true", "1", "false", "0":
+			default:
+				errs = append(errs, fmt.Errorf("invalid %s %q: must be true or false", key, val))
+			}
+		case slices.Contains(intConfigKeys, key):
+			if parsed, err := strconv.Atoi(strings.TrimSpace(val)); err != nil || parsed <= 0 {
+				errs = append(errs, fmt.Errorf("invalid %s %q: must be a positive integer", key, val))
+			}
+		case slices.Contains(quantityConfigKeys, key):
+			if _, err := resource.ParseQuantity(strings.TrimSpace(val)); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s %q: %w", key, val, err))
+			}
+		case key == "VSA_UPLOAD_URL":
+			if _, err := validateVsaUploadUrl(val, data["ALLOWED_UPLOAD_SCHEMES"]); err != nil {
+				errs = append(errs, err)
+			}
+		case key == "CIRCUIT_BREAKER_FAIL_MODE":
+			switch strings.ToLower(strings.TrimSpace(val)) {
+			case circuitBreakerFailOpen, "closed":
+			default:
+				errs = append(errs, fmt.Errorf("invalid %s %q: must be open or closed", key, val))
+			}
+		case key == "APPLICATION_POLICY_OVERRIDES":
+			var overrides map[string]string
+			if err := json.Unmarshal([]byte(val), &overrides); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", key, err))
+			}
+		}
+	}
+
+	// The service's own checks cover the required keys and the values only
+	// it knows how to parse. Format problems were reported above, so the
+	// parser's warnings about them aren't needed.
+	parser := &Service{logger: &zapLogger{l: gozap.NewNop()}}
+	config := parser.parseTaskRunConfig(data)
+
+	// The service clamps worker counts above MAX_WORKERS
+	for _, key := range []string{"DEFAULT_WORKERS", "WORKERS"} {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(data[key])); err == nil && parsed > maxWorkers(config) {
+			errs = append(errs, fmt.Errorf("invalid %s %q: must be at most %d", key, data[key], maxWorkers(config)))
+		}
+	}
+	return append(errs, splitJoinedErrors(validateTaskRunConfig(config))...)
+}
+
+// closestConfigKey returns the known key a mistyped key was most likely meant
+// to be, or an empty string if none is close enough
+func closestConfigKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if distance := editDistance(strings.ToUpper(key), candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// readConfigMapFile reads the data of a ConfigMap manifest in YAML or JSON
+func readConfigMapFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var configMap corev1.ConfigMap
+	if err := yaml.Unmarshal(content, &configMap); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if configMap.Kind != "" && configMap.Kind != "ConfigMap" {
+		return nil, fmt.Errorf("%s is a %s, not a ConfigMap", path, configMap.Kind)
+	}
+	return configMap.Data, nil
+}
+
+// readClusterConfigMap reads the data of a ConfigMap in the cluster
+func readClusterConfigMap(ctx context.Context, client K8sClient, namespace, name string) (map[string]string, error) {
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, name, err)
+	}
+	return configMap.Data, nil
+}
+
+// runValidateConfig implements the validate-config command, which checks a
+// ConfigMap from a file or the cluster and returns the exit code
+func runValidateConfig(ctx context.Context, args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	flags.SetOutput(out)
+	file := flags.String("file", "", "ConfigMap manifest to check, in YAML or JSON")
+	namespace := flags.String("namespace", "", "namespace of the ConfigMap to check in the cluster")
+	name := flags.String("name", "taskrun-config", "name of the ConfigMap to check in the cluster")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if (*file == "") == (*namespace == "") {
+		fmt.Fprintln(out, "Exactly one of -file or -namespace is required")
+		flags.Usage()
+		return 2
+	}
+
+	var data map[string]string
+	var err error
+	source := *file
+	if *file != "" {
+		data, err = readConfigMapFile(*file)
+	} else {
+		source = *namespace + "/" + *name
+		data, err = readLiveConfigMap(ctx, *namespace, *name)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Unable to read ConfigMap: %v\n", err)
+		return 2
+	}
+
+	errs := ValidateConfigMap(data)
+	if len(errs) == 0 {
+		fmt.Fprintf(out, "%s is valid\n", source)
+		return 0
+	}
+	fmt.Fprintf(out, "%s has %d problem(s):\n", source, len(errs))
+	for _, err := range errs {
+		fmt.Fprintf(out, "  - %v\n", err)
+	}
+	return 1
+}
+
+func readLiveConfigMap(ctx context.Context, namespace, name string) (map[string]string, error) {
+	k8sConfig, err := k8s.NewK8sConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	return readClusterConfigMap(ctx, &realK8sClient{client: client}, namespace, name)
+}
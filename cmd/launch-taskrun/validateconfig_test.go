@@ -0,0 +1,196 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigMap(t *testing.T) {
+	valid := func() map[string]string {
+		return map[string]string{
+			"TASK_NAME":                    "generate-vsa",
+			"VSA_UPLOAD_URL":               "rekor@https://rekor.sigstore.dev",
+			"IGNORE_REKOR":                 "true",
+			"WORKERS":                      "4",
+			"TASK_MEMORY_LIMIT":            "1Gi",
+			"CIRCUIT_BREAKER_FAIL_MODE":    "open",
+			"APPLICATION_POLICY_OVERRIDES": `{"my-app":"ns/policy"}`,
+			"DEBUG":                        "",
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(data map[string]string)
+		expected []string
+	}{
+		{name: "valid", change: func(map[string]string) {}},
+		{
+			name: "typos",
+			change: func(data map[string]string) {
+				data["WORKER"] = data["WORKERS"]
+				delete(data, "WORKERS")
+				data["ignore_rekor"] = "true"
+				data["SOMETHING_ELSE"] = "x"
+			},
+			expected: []string{
+				"unknown key SOMETHING_ELSE",
+				"unknown key WORKER, did you mean WORKERS?",
+				"unknown key ignore_rekor, did you mean IGNORE_REKOR?",
+			},
+		},
+		{
+			name: "bad values",
+			change: func(data map[string]string) {
+				data["IGNORE_REKOR"] = "yes"
+				data["WORKERS"] = "-1"
+				data["TASK_MEMORY_LIMIT"] = "lots"
+				data["VSA_UPLOAD_URL"] = "http://rekor.example.com"
+				data["CIRCUIT_BREAKER_FAIL_MODE"] = "sideways"
+				data["APPLICATION_POLICY_OVERRIDES"] = "my-app=ns/policy"
+				data["TASK_KIND"] = "pipeline"
+			},
+			expected: []string{
+				"invalid APPLICATION_POLICY_OVERRIDES",
+				`invalid CIRCUIT_BREAKER_FAIL_MODE "sideways"`,
+				`invalid IGNORE_REKOR "yes"`,
+				`invalid TASK_MEMORY_LIMIT "lots"`,
+				`scheme "http" is not allowed`,
+				`invalid WORKERS "-1"`,
+				"TASK_KIND",
+			},
+		},
+		{
+			name: "too many workers",
+			change: func(data map[string]string) {
+				data["WORKERS"] = "12"
+				data["DEFAULT_WORKERS"] = "16"
+				data["MAX_WORKERS"] = "8"
+			},
+			expected: []string{
+				`invalid DEFAULT_WORKERS "16": must be at most 8`,
+				`invalid WORKERS "12": must be at most 8`,
+			},
+		},
+		{
+			name: "missing required keys",
+			change: func(data map[string]string) {
+				delete(data, "TASK_NAME")
+				delete(data, "VSA_UPLOAD_URL")
+			},
+			expected: []string{
+				"TASK_NAME is required",
+				"VSA upload URL is not set",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := valid()
+			tt.change(data)
+
+			errs := ValidateConfigMap(data)
+
+			require.Len(t, errs, len(tt.expected), "%v", errs)
+			for i, expected := range tt.expected {
+				assert.ErrorContains(t, errs[i], expected)
+			}
+		})
+	}
+}
+
+func TestValidateConfigMap_KnowsEveryKey(t *testing.T) {
+	// The example config map is what people start from, so it must pass
+	data, err := readConfigMapFile("../../config/base/configmap.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, ValidateConfigMap(data))
+
+	for _, key := range append(append(boolConfigKeys, intConfigKeys...), quantityConfigKeys...) {
+		assert.Contains(t, knownConfigKeys(), key)
+	}
+}
+
+func TestReadClusterConfigMap(t *testing.T) {
+	mockK8s := &mockK8sClient{}
+	setupConfigMapMock(mockK8s, "test-namespace", map[string]string{"TASK_NAME": "generate-vsa"})
+
+	data, err := readClusterConfigMap(context.Background(), mockK8s, "test-namespace", "taskrun-config")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"TASK_NAME": "generate-vsa"}, data)
+}
+
+func TestRunValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	good := write("good.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: taskrun-config
+data:
+  TASK_NAME: generate-vsa
+  VSA_UPLOAD_URL: https://upload.example.com
+`)
+	bad := write("bad.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: taskrun-config
+data:
+  TASK_NAME: generate-vsa
+  VSA_UPLOAD_URL: https://upload.example.com
+  STRIKT: "true"
+`)
+	secret := write("secret.yaml", `apiVersion: v1
+kind: Secret
+metadata:
+  name: taskrun-config
+`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		exitCode int
+		output   string
+	}{
+		{name: "valid", args: []string{"-file", good}, exitCode: 0, output: "is valid"},
+		{name: "invalid", args: []string{"-file", bad}, exitCode: 1, output: "unknown key STRIKT, did you mean STRICT?"},
+		{name: "not a config map", args: []string{"-file", secret}, exitCode: 2, output: "is a Secret, not a ConfigMap"},
+		{name: "no source", args: nil, exitCode: 2, output: "Exactly one of -file or -namespace is required"},
+		{name: "two sources", args: []string{"-file", good, "-namespace", "test"}, exitCode: 2, output: "Exactly one of -file or -namespace is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Equal(t, tt.exitCode, runValidateConfig(context.Background(), tt.args, &out))
+			assert.Contains(t, out.String(), tt.output)
+		})
+	}
+}
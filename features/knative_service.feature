@@ -28,6 +28,26 @@ Feature: Knative Service Task Triggering
     And the TaskRun should reference the enterprise contract bundle
     And the TaskRun should succeed
 
+  Scenario: TaskRun uses the policy from the ReleasePlanAdmission
+    Given a ReleasePlan for application "rpa-test-app" targeting namespace "rpa-target" with policy "rpa-test-policy"
+    And a valid snapshot with specification
+    """
+    {
+      "application": "rpa-test-app",
+      "displayName": "rpa-test-snapshot",
+      "components": [
+        {
+          "name": "rpa-test-component",
+          "containerImage": "quay.io/redhat-user-workloads/rhtap-contract-tenant/golden-container/golden-container@sha256:185f6c39e5544479863024565bb7e63c6f2f0547c3ab4ddf99ac9b5755075cc9"
+        }
+      ]
+    }
+    """
+    When the snapshot is created in the cluster
+    Then a TaskRun should be created
+    And the TaskRun should have the correct parameters
+    And the TaskRun should use the policy from the ReleasePlanAdmission
+
   Scenario: Multiple components in snapshot
     Given a valid snapshot with multiple components
     """
@@ -34,7 +34,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -83,11 +82,8 @@ func main() {
 
 	fmt.Printf("Found snapshot: %s\n", snapshot.Name)
 
-	// Extract application name from raw JSON spec
-	var spec struct {
-		Application string `json:"application"`
-	}
-	if err = json.Unmarshal(snapshot.Spec, &spec); err != nil {
+	spec, err := konflux.ParseSnapshotSpec(snapshot.Spec)
+	if err != nil {
 		log.Fatalf("Failed to extract application from spec: %v", err)
 	}
 	fmt.Printf("Application name: %s\n", spec.Application)